@@ -10,7 +10,7 @@ type Config struct {
 	// This concurrent option is related with all test profiles
 	// (Test profiles are going to be run immediately or have to
 	// wait other test profiles to be finished?)
-	Concurrent bool `json:"concurrent`
+	Concurrent bool `json:"concurrent"`
 }
 
 type TestProfile struct {
@@ -23,14 +23,330 @@ type TestProfile struct {
 	// Do Not Mix With Overall Concurrent Option.
 	// This concurrent option is related with just the given test profile,
 	// not all test profile.
-	Concurrent bool `json:"concurrent`
+	Concurrent bool `json:"concurrent"`
+
+	// Workers is the maximum number of nodes that are tested at the same
+	// time when Concurrent is true. If it is not set, every node of the test
+	// profile is tested at the same time.
+	Workers int `json:"workers"`
 
 	// TODO: change key
 	RoundRobin bool `json:"roundRobin"`
 
-	// if CallContractMethod is true, gohammer calls setItem function from
-	// smart constract instead of deploying contract.
+	// Dispatch is how the transactions of a round robin test profile are
+	// distributed to its nodes: "roundRobin" (default), "weighted" by the
+	// node weights, "leastOutstanding" to the node with the fewest
+	// transactions in flight or "random".
+	Dispatch string `json:"dispatch"`
+
+	// if CallContractMethod is true, gohammer calls the method of the
+	// smart contract (setItem of the Store contract by default) instead of
+	// deploying contract.
 	CallContractMethod bool `json:"callContractMethod"`
+
+	// Contract is the contract that is deployed and called by the test
+	// profile. If it is not set, the built-in Store contract is used.
+	Contract *ContractConfig `json:"contract"`
+
+	// Transfer makes the test profile send plain value transfers instead
+	// of deploying or calling a contract.
+	Transfer *TransferConfig `json:"transfer"`
+
+	// PreSign makes the test profile sign its transactions before the test
+	// starts, so only sending the raw transactions is measured.
+	PreSign *PreSignConfig `json:"preSign"`
+
+	// Rate is the default target transaction rate of the nodes in the test
+	// profile (e.g. "200/s", "30/m"). It is used for the nodes which don't
+	// have their own rate. In round robin profiles it is the overall rate
+	// shared by all nodes.
+	Rate string `json:"rate"`
+
+	// Phases describes the load shape of the test profile. If it is set,
+	// phases are run in order on every node (or on all nodes together in
+	// round robin profiles) instead of the node deploy counts.
+	Phases []Phase `json:"phases"`
+
+	// GasPriceRefreshInterval is how long the suggested gas price of a node
+	// is cached before it is fetched again ("10s", "1m" etc.), default is 10s.
+	GasPriceRefreshInterval string `json:"gasPriceRefreshInterval"`
+
+	// TxType is the type of the sent transactions: "legacy" (default),
+	// "accessList" (EIP-2930) or "dynamicFee" (EIP-1559). Transactions are
+	// signed with the chain ID of the node (EIP-155).
+	TxType string `json:"txType"`
+
+	// AccessList is the access list of accessList and dynamicFee
+	// transactions.
+	AccessList []AccessTuple `json:"accessList"`
+
+	// Fees configures the fees of dynamicFee transactions.
+	Fees *FeeConfig `json:"fees"`
+
+	// Gas configures the gas limit of the transactions, if it is not set
+	// contract transactions have a gas limit of 300000 and transfers use
+	// their own gas limit.
+	Gas *GasConfig `json:"gas"`
+
+	// Errors configures how failed transactions are handled by their error
+	// class.
+	Errors *ErrorConfig `json:"errors"`
+
+	// OnFailure decides what happens when a node of the test profile fails:
+	// "continue" stops the test profile and runs the other test profiles,
+	// "skipNode" continues the test profile without the node and "abort"
+	// (default) stops the run.
+	OnFailure string `json:"onFailure"`
+
+	// Receipts enables the transaction receipt tracker if it is set.
+	Receipts *ReceiptConfig `json:"receipts"`
+
+	// Stop configures the conditions that stop the test profile, if it is
+	// set the load of the test profile is repeated until one of them is
+	// met.
+	Stop *StopConfig `json:"stop"`
+
+	// Batch sends the transactions in JSON-RPC batch requests if it is
+	// set.
+	Batch *BatchConfig `json:"batch"`
+
+	// Mix makes the test profile send a mix of transaction types instead
+	// of a single one if it is set.
+	Mix *MixConfig `json:"mix"`
+
+	// Reads adds a read workload that runs on every node at the same time
+	// as the write workload if it is set.
+	Reads *ReadConfig `json:"reads"`
+}
+
+// AccessTuple is an address and the storage keys of it that a transaction
+// accesses.
+type AccessTuple struct {
+	Address     string   `json:"address"`
+	StorageKeys []string `json:"storageKeys"`
+}
+
+// FeeConfig describes how the fees of dynamic fee transactions are chosen.
+// They are refreshed from the node like the gas price.
+type FeeConfig struct {
+	// MaxFee is the max fee per gas in wei, or a multiplier of the base fee
+	// of the latest block like "2x" (default), the priority fee is added to
+	// the multiplied base fee.
+	MaxFee string `json:"maxFee"`
+
+	// PriorityFee is the max priority fee per gas in wei, or "suggested"
+	// (default) for the eth_maxPriorityFeePerGas of the node.
+	PriorityFee string `json:"priorityFee"`
+}
+
+// GasConfig describes how the gas limit of the transactions is chosen.
+type GasConfig struct {
+	// Policy is "fixed" (default), "estimateOnce" to estimate the gas of the
+	// first deploy and the first call of every node with eth_estimateGas
+	// and reuse it, or "estimate" to estimate every transaction.
+	Policy string `json:"policy"`
+
+	// Limit is the gas limit of the fixed policy, default is the gas limit
+	// of the workload.
+	Limit uint64 `json:"limit"`
+
+	// Multiplier is applied to the estimated gas, e.g. 1.2 adds a 20%
+	// margin. Default is 1.
+	Multiplier float64 `json:"multiplier"`
+}
+
+// ErrorConfig describes the policies of the transaction error classes.
+type ErrorConfig struct {
+	// Policies maps error classes ("nonceTooLow", "replacementUnderpriced",
+	// "txpoolFull", "insufficientFunds", "connectionReset", "timeout" and
+	// "other") to "retry", "resync", "skip" or "abort". Classes that are
+	// not set use their default policy.
+	Policies map[string]string `json:"policies"`
+
+	// Retries is the maximum number of times a transaction is resent by
+	// the retry policy, default is 3.
+	Retries int `json:"retries"`
+
+	// Backoff is the delay before the first retry, it is doubled on every
+	// retry. Default is "100ms".
+	Backoff string `json:"backoff"`
+}
+
+// ContractConfig describes a contract by its ABI and bytecode files.
+type ContractConfig struct {
+	Name string `json:"name"`
+
+	// ABIFile is the path of the contract ABI JSON file.
+	ABIFile string `json:"abi"`
+
+	// BinFile is the path of the file that contains the hex encoded
+	// contract bytecode.
+	BinFile string `json:"bin"`
+
+	// ConstructorArgs and MethodArgs are JSON values converted to the ABI
+	// types of the arguments. An argument given as an object with a
+	// "generator" key is generated for every transaction instead (counter,
+	// randomBytes, random, pick, template or uuid).
+	ConstructorArgs []interface{} `json:"constructorArgs"`
+
+	// Method is called with MethodArgs when CallContractMethod is true.
+	Method     string        `json:"method"`
+	MethodArgs []interface{} `json:"methodArgs"`
+
+	// Seed is the seed of the random argument generators, the same seed
+	// generates the same arguments.
+	Seed int64 `json:"seed"`
+
+	// Address is the address of an already deployed contract. If it is
+	// set, the method is called on it instead of deploying the contract
+	// first.
+	Address string `json:"address"`
+}
+
+// TransferConfig describes a native value transfer workload.
+type TransferConfig struct {
+	// To is the recipient of the transfers: an address, "random" for a new
+	// random address on every transfer or "senders" for the sender accounts
+	// of the node in turn. Default is "senders".
+	To string `json:"to"`
+
+	// Amount is the transferred value in wei, default is 1.
+	Amount string `json:"amount"`
+
+	// GasLimit is the gas limit of the transfers, default is 21000.
+	GasLimit uint64 `json:"gasLimit"`
+}
+
+// PreSignConfig describes how the transactions of a test profile are signed
+// before the test.
+type PreSignConfig struct {
+	// Count is the number of transactions signed for every sender account
+	// of a node. Default is enough for the deploy counts of the node, it
+	// is required if the test profile has phases.
+	Count int `json:"count"`
+
+	// Output is the file the signed transactions are written to, so they
+	// can be replayed later.
+	Output string `json:"output"`
+
+	// Input is a file written by an earlier run. If it is set, its
+	// transactions are sent instead of signing new ones.
+	Input string `json:"input"`
+}
+
+// ReceiptConfig configures how the receipts of the sent transactions are
+// tracked.
+type ReceiptConfig struct {
+	// PollInterval is how often the pending receipts are fetched, default
+	// is 1s.
+	PollInterval string `json:"pollInterval"`
+
+	// Timeout is how long a transaction can wait for its receipt before it
+	// is counted as dropped, default is 2m.
+	Timeout string `json:"timeout"`
+}
+
+// ReadConfig describes a read workload, the enabled read types are issued in
+// turn at the target rate.
+type ReadConfig struct {
+	// Rate is the target read rate of every node, e.g. "500/s".
+	Rate string `json:"rate"`
+
+	// Method is a view method of the contract that is called with
+	// eth_call, e.g. "version" or "items" of the Store contract. MethodArgs
+	// are its arguments, they can be generators like the contract method
+	// arguments.
+	Method     string        `json:"method"`
+	MethodArgs []interface{} `json:"methodArgs"`
+
+	// Balance reads the balances of the sender accounts of the node with
+	// eth_getBalance.
+	Balance bool `json:"balance"`
+
+	// Logs reads the logs of the contract in the last LogsBlockRange blocks
+	// (default 100) with eth_getLogs.
+	Logs           bool   `json:"logs"`
+	LogsBlockRange uint64 `json:"logsBlockRange"`
+
+	// Address is the address of the contract that is read, default is the
+	// contract address of the test profile or an instance deployed on every
+	// node.
+	Address string `json:"address"`
+}
+
+// MixConfig is the percentage of every transaction type in a mixed
+// workload, the percentages have to add up to 100. Calls and deploys use
+// the contract of the test profile and transfers use its transfer config.
+type MixConfig struct {
+	Deploy   float64 `json:"deploy"`
+	Call     float64 `json:"call"`
+	Transfer float64 `json:"transfer"`
+
+	// Seed is the seed of the random draws, the same seed draws the same
+	// sequence of transaction types.
+	Seed int64 `json:"seed"`
+}
+
+// BatchConfig describes how the transactions are grouped into JSON-RPC
+// batch requests.
+type BatchConfig struct {
+	// Size is the maximum number of transactions in a batch.
+	Size int `json:"size"`
+
+	// FlushInterval is how long a batch waits for more transactions after
+	// its first one before it is sent, default is 10ms.
+	FlushInterval string `json:"flushInterval"`
+}
+
+// StopConfig describes when a test profile stops, the first condition that
+// is met stops it. Unset conditions are not checked.
+type StopConfig struct {
+	// Duration is the wall-clock duration of the test profile, e.g. "12h".
+	Duration string `json:"duration"`
+
+	// MinedTxs is the number of mined transactions of the test profile,
+	// receipts have to be tracked.
+	MinedTxs int `json:"minedTxs"`
+
+	// ErrorRate is the ratio of the failed transactions to all
+	// transactions of the test profile, e.g. 0.05. It is checked after
+	// MinTxs transactions (default 100).
+	ErrorRate float64 `json:"errorRate"`
+	MinTxs    int     `json:"minTxs"`
+
+	// BlockNumber is the block height of the chain, it is fetched from the
+	// first node of the test profile.
+	BlockNumber uint64 `json:"blockNumber"`
+}
+
+// Phase is a stage of a test profile load shape. Rates are given in the
+// same format with the Rate fields ("200/s") and durations are Go duration
+// strings ("30s", "2h").
+type Phase struct {
+	Name string `json:"name"`
+
+	// Type of the phase:
+	//   ramp:  rate changes linearly From -> To over Duration.
+	//   hold:  constant Rate for Duration.
+	//   step:  starts at From and increases by Step every StepDuration,
+	//          never exceeds To if it is set.
+	//   spike: constant Rate, with bursts of SpikeRate lasting
+	//          SpikeDuration at the beginning of every SpikeInterval
+	//          (just once at the start if SpikeInterval is not set).
+	//   soak:  constant Rate for a long Duration.
+	Type     string `json:"type"`
+	Duration string `json:"duration"`
+
+	Rate string `json:"rate"`
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	Step         string `json:"step"`
+	StepDuration string `json:"stepDuration"`
+
+	SpikeRate     string `json:"spikeRate"`
+	SpikeDuration string `json:"spikeDuration"`
+	SpikeInterval string `json:"spikeInterval"`
 }
 
 type NodeConfig struct {
@@ -39,4 +355,83 @@ type NodeConfig struct {
 	Cipher         string `json:"cipher"`
 	DeployCounts   []int  `json:"deployCounts"`
 	DeployInterval string `json:"deployInterval"`
+
+	// Keystore is the path of an encrypted keystore JSON file that is used
+	// instead of Cipher. Its password is read from the first line of
+	// PasswordFile or from the PasswordEnv environment variable.
+	Keystore     string `json:"keystore"`
+	PasswordFile string `json:"passwordFile"`
+	PasswordEnv  string `json:"passwordEnv"`
+
+	// Rate is the target transaction rate of the node (e.g. "200/s").
+	// If it is set, transactions are issued on a fixed timeline regardless
+	// of how long each send takes, otherwise they are sent back-to-back.
+	Rate string `json:"rate"`
+
+	// Senders is a pool of accounts that transactions of the node are sent
+	// from in turn. If it is set, Cipher and Keystore are not used.
+	Senders *SenderPool `json:"senders"`
+
+	// Private makes the transactions of the node Quorum private
+	// transactions if it is set.
+	Private *PrivateConfig `json:"private"`
+
+	// Weight is the share of the node in the transactions of a test profile
+	// with the weighted dispatch strategy, default is 1.
+	Weight int `json:"weight"`
+
+	// Signer makes an external signer sign the transactions of the node
+	// instead of local keys, Cipher, Keystore and Senders can't be set
+	// with it.
+	Signer *SignerConfig `json:"signer"`
+}
+
+// SignerConfig describes an external signer that supports the Clef
+// account_signTransaction API.
+type SignerConfig struct {
+	// URL is the JSON-RPC endpoint of the signer (e.g. "http://localhost:8550"
+	// or the path of the Clef IPC socket).
+	URL string `json:"url"`
+
+	// Accounts are the addresses of the signer accounts that transactions
+	// of the node are sent from in turn.
+	Accounts []string `json:"accounts"`
+}
+
+// PrivateConfig describes the private transactions of a node. The payload
+// of every transaction is stored in the privacy manager and only its hash is
+// sent to the node.
+type PrivateConfig struct {
+	// PrivacyManagerURL is the third party API URL of the privacy manager
+	// of the node (e.g. Tessera "http://localhost:9081").
+	PrivacyManagerURL string `json:"privacyManagerUrl"`
+
+	// PrivateFrom is the base64 public key of the sender enclave, the
+	// default key of the privacy manager is used if it is not set.
+	PrivateFrom string `json:"privateFrom"`
+
+	// PrivateFor are the base64 public keys of the recipient enclaves.
+	PrivateFor []string `json:"privateFor"`
+}
+
+// SenderPool describes the sender accounts of a node, they are either
+// derived from a BIP-39 mnemonic or loaded from a directory of keystore
+// files.
+type SenderPool struct {
+	Mnemonic   string `json:"mnemonic"`
+	Passphrase string `json:"passphrase"`
+
+	// DerivationPath is the base BIP-32 path of the mnemonic accounts, the
+	// account index is appended to it. Default is m/44'/60'/0'/0.
+	DerivationPath string `json:"derivationPath"`
+
+	// Count is the number of accounts derived from the mnemonic or the
+	// maximum number of accounts loaded from the keystore directory.
+	Count int `json:"count"`
+
+	// KeystoreDir is decrypted with the password read from PasswordFile or
+	// from the PasswordEnv environment variable.
+	KeystoreDir  string `json:"keystoreDir"`
+	PasswordFile string `json:"passwordFile"`
+	PasswordEnv  string `json:"passwordEnv"`
 }
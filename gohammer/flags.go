@@ -1,6 +1,10 @@
 package main
 
-import "gopkg.in/urfave/cli.v1"
+import (
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/tubuarge/GoHammer/store"
+)
 
 var (
 	DeployNodeUrlFlag = cli.StringFlag{
@@ -37,4 +41,10 @@ var (
 		Name:  "logdir",
 		Usage: `--logdir <path>`,
 	}
+
+	GracePeriodFlag = cli.DurationFlag{
+		Name:  "graceperiod",
+		Usage: `--graceperiod <duration>, how long to wait for transaction receipts after an interrupt`,
+		Value: store.DefaultGracePeriod,
+	}
 )
@@ -4,7 +4,9 @@ go 1.15
 
 require (
 	github.com/Workiva/go-datastructures v1.0.53
-	github.com/ethereum/go-ethereum v1.10.1
+	github.com/ethereum/go-ethereum v1.10.8
 	github.com/sirupsen/logrus v1.8.1
+	github.com/tyler-smith/go-bip39 v1.0.1-0.20181017060643-dbb3b84ba2ef
+	golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2
 	gopkg.in/urfave/cli.v1 v1.20.0
 )
@@ -0,0 +1,152 @@
+package logger
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// histogramSubBucketBits is the number of bits used for the sub-buckets of
+// every power of two, 128 sub-buckets keep the relative error of the recorded
+// values under 1%.
+const histogramSubBucketBits = 7
+
+// SummaryPercentiles are the percentiles reported by Histogram.Summary.
+var SummaryPercentiles = []float64{50, 90, 95, 99, 99.9}
+
+// Histogram records durations into logarithmic buckets with linear
+// sub-buckets, like an HDR histogram, so percentiles can be reported with a
+// bounded relative error and constant memory no matter how many values are
+// recorded. Min, max and mean are exact.
+//
+// Histogram is not safe for concurrent use.
+type Histogram struct {
+	counts []int64
+
+	count int64
+	sum   time.Duration
+	min   time.Duration
+	max   time.Duration
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{
+		counts: make([]int64, (64-histogramSubBucketBits+1)<<histogramSubBucketBits),
+	}
+}
+
+// Record adds the given duration to the histogram, negative durations are
+// recorded as zero.
+func (h *Histogram) Record(value time.Duration) {
+	if value < 0 {
+		value = 0
+	}
+
+	h.counts[bucketIndex(uint64(value))]++
+	if h.count == 0 || value < h.min {
+		h.min = value
+	}
+	if value > h.max {
+		h.max = value
+	}
+	h.count++
+	h.sum += value
+}
+
+// Merge adds every value recorded in other to the histogram.
+func (h *Histogram) Merge(other *Histogram) {
+	if other.count == 0 {
+		return
+	}
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+	h.count += other.count
+	h.sum += other.sum
+}
+
+func (h *Histogram) Count() int64 {
+	return h.count
+}
+
+func (h *Histogram) Min() time.Duration {
+	return h.min
+}
+
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
+
+// Percentile returns the value below which the given percentage of the
+// recorded values fall.
+func (h *Histogram) Percentile(percentile float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	rank := int64(math.Ceil(percentile / 100 * float64(h.count)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen int64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			value := time.Duration(bucketValue(i))
+			if value < h.min {
+				return h.min
+			}
+			if value > h.max {
+				return h.max
+			}
+			return value
+		}
+	}
+	return h.max
+}
+
+// Summary returns min, mean, max and the SummaryPercentiles of the histogram
+// in a single line.
+func (h *Histogram) Summary() string {
+	summary := fmt.Sprintf("count: %d, min: %s, mean: %s", h.count, h.Min(), h.Mean())
+	for _, percentile := range SummaryPercentiles {
+		summary += fmt.Sprintf(", p%g: %s", percentile, h.Percentile(percentile))
+	}
+	return summary + fmt.Sprintf(", max: %s", h.Max())
+}
+
+// bucketIndex returns the bucket of the given value. Values under
+// 2^(histogramSubBucketBits+1) have their own buckets, larger values share
+// the buckets of their power of two.
+func bucketIndex(value uint64) int {
+	shift := 0
+	if length := bits.Len64(value); length > histogramSubBucketBits+1 {
+		shift = length - histogramSubBucketBits - 1
+	}
+	return shift<<histogramSubBucketBits + int(value>>uint(shift))
+}
+
+// bucketValue returns the middle value of the given bucket.
+func bucketValue(index int) uint64 {
+	shift := 0
+	if index >= 2<<histogramSubBucketBits {
+		shift = index>>histogramSubBucketBits - 1
+	}
+	lowest := uint64(index-shift<<histogramSubBucketBits) << uint(shift)
+	return lowest + (uint64(1)<<uint(shift))/2
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistogramPercentiles(t *testing.T) {
+	h := NewHistogram()
+	// 1ms, 2ms, ..., 1000ms
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	if h.Count() != 1000 {
+		t.Errorf("count = %d, want 1000", h.Count())
+	}
+	if h.Min() != time.Millisecond || h.Max() != time.Second {
+		t.Errorf("min = %s, max = %s, want 1ms and 1s", h.Min(), h.Max())
+	}
+	if h.Mean() != 500500*time.Microsecond {
+		t.Errorf("mean = %s, want 500.5ms", h.Mean())
+	}
+
+	for _, percentile := range []float64{50, 90, 99, 99.9} {
+		want := float64(time.Duration(percentile*10) * time.Millisecond)
+		got := float64(h.Percentile(percentile))
+		if math.Abs(got-want)/want > 0.01 {
+			t.Errorf("p%g = %s, want %s", percentile, time.Duration(got), time.Duration(want))
+		}
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	a, b := NewHistogram(), NewHistogram()
+	a.Record(10 * time.Millisecond)
+	b.Record(time.Millisecond)
+	b.Record(time.Second)
+
+	a.Merge(b)
+	if a.Count() != 3 || a.Min() != time.Millisecond || a.Max() != time.Second {
+		t.Errorf("merged count = %d, min = %s, max = %s", a.Count(), a.Min(), a.Max())
+	}
+	if p50 := a.Percentile(50); p50 < 9*time.Millisecond || p50 > 11*time.Millisecond {
+		t.Errorf("merged p50 = %s, want ~10ms", p50)
+	}
+}
+
+func TestBucketIndex(t *testing.T) {
+	previous := -1
+	for _, value := range []uint64{0, 1, 255, 256, 257, 1 << 20, 1<<63 - 1} {
+		index := bucketIndex(value)
+		if index < previous {
+			t.Errorf("bucket index of %d is %d, decreasing", value, index)
+		}
+		middle := bucketValue(index)
+		if value > 1000 && math.Abs(float64(middle)-float64(value))/float64(value) > 0.01 {
+			t.Errorf("bucket value of %d is %d", value, middle)
+		}
+		previous = index
+	}
+}
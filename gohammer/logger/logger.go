@@ -3,6 +3,8 @@ package logger
 import (
 	"fmt"
 	"os"
+	"sort"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -20,12 +22,261 @@ type TestResults struct {
 	TestEndTimestamp     time.Time
 	OverallExecutionTime time.Duration
 
+	// Interrupted is true if the test is stopped by a signal, the results
+	// are partial then.
+	Interrupted bool
+
 	TotalTxCount int
+
+	// PrivateTxCount is the number of private transactions, they are
+	// included in TotalTxCount.
+	PrivateTxCount int
+
+	// RateResults contains target and achieved transaction rates of
+	// the rate limited test runs.
+	RateResults []RateResult
+
+	// Receipt results of the sent transactions, they are counted only if
+	// receipts are tracked.
+	MinedTxCount    int
+	RevertedTxCount int
+	DroppedTxCount  int
+
+	// UnconfirmedTxCount is the number of transactions whose receipts are
+	// not fetched before the grace period of an interrupted test is over.
+	UnconfirmedTxCount int
+
+	// OutOfGasTxCount is the number of transactions that ran out of gas.
+	// They are either mined as failed transactions that used all of their
+	// gas (they are also counted as reverted, so the mined, reverted and
+	// dropped counts add up to the tracked transactions) or rejected by the
+	// node because of their gas limit.
+	OutOfGasTxCount int
+
+	// BatchCount is the number of JSON-RPC batch requests and
+	// BatchedTxCount is the number of transactions sent in them.
+	BatchCount     int
+	BatchedTxCount int
+
+	// WorkloadTxCounts and WorkloadErrorCounts are the number of sent and
+	// failed transactions of every transaction type of the mixed
+	// workloads.
+	WorkloadTxCounts    map[string]int
+	WorkloadErrorCounts map[string]int
+
+	// ReadResults contains the results of the read workloads by read
+	// type.
+	ReadResults map[string]*ReadResult
+
+	// DispatchedTxCounts is the number of transactions dispatched to every
+	// node of the round robin test profiles.
+	DispatchedTxCounts map[string]int
+
+	// ErrorCounts is the number of failed transactions by their error
+	// class.
+	ErrorCounts map[string]int
+
+	// Latencies contains the latency histograms of all nodes and
+	// NodeLatencies contains them per node.
+	Latencies     *LatencyResult
+	NodeLatencies map[string]*LatencyResult
+
+	mu sync.Mutex
+}
+
+// ReadResult contains the read count, the failed read count and the
+// latency histogram of the successful reads of a read type.
+type ReadResult struct {
+	Count      int
+	ErrorCount int
+	Latency    *Histogram
+}
+
+// LatencyResult contains the RPC send latency and inclusion (submit to
+// receipt) latency histograms. Inclusion latencies are recorded only if
+// receipts are tracked.
+type LatencyResult struct {
+	SendLatency      *Histogram
+	InclusionLatency *Histogram
+}
+
+func NewLatencyResult() *LatencyResult {
+	return &LatencyResult{
+		SendLatency:      NewHistogram(),
+		InclusionLatency: NewHistogram(),
+	}
+}
+
+// RateResult is the outcome of a test run that is driven by a target rate.
+type RateResult struct {
+	Name         string
+	TxCount      int
+	TargetRate   float64
+	AchievedRate float64
+}
+
+// AddTxCount increases the total transaction count, it is safe to call from
+// multiple goroutines.
+func (t *TestResults) AddTxCount(count int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.TotalTxCount += count
+}
+
+// AddPrivateTxCount increases the private transaction count, the total
+// transaction count has to be increased separately.
+func (t *TestResults) AddPrivateTxCount(count int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.PrivateTxCount += count
+}
+
+// AddSendLatency records how long sending a transaction to the given node
+// took.
+func (t *TestResults) AddSendLatency(nodeName string, sendLatency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	latencies, nodeLatencies := t.latencies(nodeName)
+	latencies.SendLatency.Record(sendLatency)
+	nodeLatencies.SendLatency.Record(sendLatency)
+}
+
+// AddMinedTx counts a transaction of the given node whose receipt is
+// received after the given inclusion latency.
+func (t *TestResults) AddMinedTx(nodeName string, inclusionLatency time.Duration, reverted bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if reverted {
+		t.RevertedTxCount++
+	} else {
+		t.MinedTxCount++
+	}
+
+	latencies, nodeLatencies := t.latencies(nodeName)
+	latencies.InclusionLatency.Record(inclusionLatency)
+	nodeLatencies.InclusionLatency.Record(inclusionLatency)
+}
+
+// AddOutOfGasTx counts a transaction that ran out of gas. A mined one is
+// counted by AddMinedTx as reverted as well.
+func (t *TestResults) AddOutOfGasTx() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OutOfGasTxCount++
+}
+
+// AddError counts a failed transaction of the given error class.
+func (t *TestResults) AddError(class string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ErrorCounts == nil {
+		t.ErrorCounts = make(map[string]int)
+	}
+	t.ErrorCounts[class]++
+}
+
+// latencies returns the overall and the given node's latency results,
+// creating them if they don't exist. t.mu must be held.
+func (t *TestResults) latencies(nodeName string) (*LatencyResult, *LatencyResult) {
+	if t.Latencies == nil {
+		t.Latencies = NewLatencyResult()
+	}
+	if t.NodeLatencies == nil {
+		t.NodeLatencies = make(map[string]*LatencyResult)
+	}
+	nodeLatencies, ok := t.NodeLatencies[nodeName]
+	if !ok {
+		nodeLatencies = NewLatencyResult()
+		t.NodeLatencies[nodeName] = nodeLatencies
+	}
+	return t.Latencies, nodeLatencies
+}
+
+// AddDroppedTx counts a transaction that never got a receipt.
+func (t *TestResults) AddDroppedTx() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.DroppedTxCount++
+}
+
+// AddRead counts a read of the given type and records its latency if it
+// didn't fail.
+func (t *TestResults) AddRead(readType string, latency time.Duration, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ReadResults == nil {
+		t.ReadResults = make(map[string]*ReadResult)
+	}
+	result, ok := t.ReadResults[readType]
+	if !ok {
+		result = &ReadResult{Latency: NewHistogram()}
+		t.ReadResults[readType] = result
+	}
+
+	result.Count++
+	if failed {
+		result.ErrorCount++
+		return
+	}
+	result.Latency.Record(latency)
+}
+
+// AddWorkloadTx counts a sent or failed transaction of the given type of a
+// mixed workload.
+func (t *TestResults) AddWorkloadTx(workload string, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.WorkloadTxCounts == nil {
+		t.WorkloadTxCounts = make(map[string]int)
+		t.WorkloadErrorCounts = make(map[string]int)
+	}
+	if failed {
+		t.WorkloadErrorCounts[workload]++
+	} else {
+		t.WorkloadTxCounts[workload]++
+	}
+}
+
+// AddDispatchedTx counts a transaction dispatched to the given node.
+func (t *TestResults) AddDispatchedTx(nodeName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.DispatchedTxCounts == nil {
+		t.DispatchedTxCounts = make(map[string]int)
+	}
+	t.DispatchedTxCounts[nodeName]++
+}
+
+// AddBatch counts a batch request of the given number of transactions.
+func (t *TestResults) AddBatch(txCount int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.BatchCount++
+	t.BatchedTxCount += txCount
+}
+
+// AddUnconfirmedTxs adds the given number of transactions whose receipts
+// are not fetched.
+func (t *TestResults) AddUnconfirmedTxs(count int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.UnconfirmedTxCount += count
+}
+
+// AddRateResult appends the given rate result to the test results.
+func (t *TestResults) AddRateResult(result RateResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.RateResults = append(t.RateResults, result)
 }
 
 type LogClient struct {
 	LogFile    *os.File
 	TestResult *TestResults
+
+	// fileMu serializes the writes of concurrently running test profiles
+	// and nodes to the log file.
+	fileMu sync.Mutex
 }
 
 func NewLogClient(logDirFile *os.File) *LogClient {
@@ -64,6 +315,9 @@ func getLogFilename(filename string) string {
 }
 
 func (l *LogClient) WriteFile(data []byte) error {
+	l.fileMu.Lock()
+	defer l.fileMu.Unlock()
+
 	_, err := l.LogFile.Write(data)
 	if err != nil {
 		return err
@@ -97,7 +351,12 @@ func (l *LogClient) WriteTestEntry(msg, entryTitle string, timestamp time.Time,
 }
 
 func (l *LogClient) WriteTestResults() error {
-	strData := fmt.Sprintf("\t\tTest Started At: %v\n"+
+	strData := ""
+	if l.TestResult.Interrupted {
+		strData += "\t\tTest Status: interrupted, results are partial\n"
+	}
+
+	strData += fmt.Sprintf("\t\tTest Started At: %v\n"+
 		"\t\tTest Ended At: %v\n"+
 		"\t\tTotal Test Execution Time: %v\n"+
 		"\t\tTotal Transaction Count: %d\n",
@@ -108,6 +367,118 @@ func (l *LogClient) WriteTestResults() error {
 		fmt.Sprintf("%s", l.TestResult.OverallExecutionTime),
 		l.TestResult.TotalTxCount)
 
+	if l.TestResult.PrivateTxCount > 0 {
+		strData += fmt.Sprintf("\t\tPublic Transaction Count: %d\n"+
+			"\t\tPrivate Transaction Count: %d\n",
+			l.TestResult.TotalTxCount-l.TestResult.PrivateTxCount,
+			l.TestResult.PrivateTxCount)
+	}
+
+	trackedTxCount := l.TestResult.MinedTxCount + l.TestResult.RevertedTxCount + l.TestResult.DroppedTxCount
+	if trackedTxCount > 0 {
+		strData += fmt.Sprintf("\t\tMined Transaction Count: %d\n"+
+			"\t\tReverted Transaction Count: %d\n"+
+			"\t\tDropped Transaction Count: %d\n",
+			l.TestResult.MinedTxCount,
+			l.TestResult.RevertedTxCount,
+			l.TestResult.DroppedTxCount)
+	}
+	if l.TestResult.UnconfirmedTxCount > 0 {
+		strData += fmt.Sprintf("\t\tUnconfirmed Transaction Count: %d\n", l.TestResult.UnconfirmedTxCount)
+	}
+	if trackedTxCount > 0 || l.TestResult.OutOfGasTxCount > 0 {
+		strData += fmt.Sprintf("\t\tOut Of Gas Transaction Count: %d\n", l.TestResult.OutOfGasTxCount)
+	}
+
+	if l.TestResult.BatchCount > 0 {
+		strData += fmt.Sprintf("\t\tBatch Request Count: %d\n"+
+			"\t\tAverage Batch Size: %.2f\n",
+			l.TestResult.BatchCount,
+			float64(l.TestResult.BatchedTxCount)/float64(l.TestResult.BatchCount))
+	}
+
+	if l.TestResult.WorkloadTxCounts != nil {
+		workloads := make(map[string]bool)
+		for workload := range l.TestResult.WorkloadTxCounts {
+			workloads[workload] = true
+		}
+		for workload := range l.TestResult.WorkloadErrorCounts {
+			workloads[workload] = true
+		}
+		var names []string
+		for workload := range workloads {
+			names = append(names, workload)
+		}
+		sort.Strings(names)
+		for _, workload := range names {
+			strData += fmt.Sprintf("\t\t[%s] Transaction Count: %d, Error Count: %d\n",
+				workload, l.TestResult.WorkloadTxCounts[workload], l.TestResult.WorkloadErrorCounts[workload])
+		}
+	}
+
+	if len(l.TestResult.ReadResults) > 0 {
+		var readTypes []string
+		for readType := range l.TestResult.ReadResults {
+			readTypes = append(readTypes, readType)
+		}
+		sort.Strings(readTypes)
+		for _, readType := range readTypes {
+			result := l.TestResult.ReadResults[readType]
+			strData += fmt.Sprintf("\t\t[read %s] Read Count: %d, Error Count: %d, Error Rate: %.2f%%\n"+
+				"\t\t[read %s] Read Latency: %s\n",
+				readType, result.Count, result.ErrorCount, float64(result.ErrorCount)/float64(result.Count)*100,
+				readType, result.Latency.Summary())
+		}
+	}
+
+	if len(l.TestResult.DispatchedTxCounts) > 0 {
+		var nodeNames []string
+		dispatchedTxCount := 0
+		for nodeName, count := range l.TestResult.DispatchedTxCounts {
+			nodeNames = append(nodeNames, nodeName)
+			dispatchedTxCount += count
+		}
+		sort.Strings(nodeNames)
+		for _, nodeName := range nodeNames {
+			count := l.TestResult.DispatchedTxCounts[nodeName]
+			strData += fmt.Sprintf("\t\t[%s] Dispatched Transaction Count: %d (%.2f%%)\n",
+				nodeName, count, float64(count)/float64(dispatchedTxCount)*100)
+		}
+	}
+
+	if len(l.TestResult.ErrorCounts) > 0 {
+		var classes []string
+		for class := range l.TestResult.ErrorCounts {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			strData += fmt.Sprintf("\t\t[%s] Error Count: %d\n", class, l.TestResult.ErrorCounts[class])
+		}
+	}
+
+	if l.TestResult.Latencies != nil {
+		strData += formatLatencies("", l.TestResult.Latencies)
+
+		var nodeNames []string
+		for nodeName := range l.TestResult.NodeLatencies {
+			nodeNames = append(nodeNames, nodeName)
+		}
+		sort.Strings(nodeNames)
+		for _, nodeName := range nodeNames {
+			strData += formatLatencies(fmt.Sprintf("[%s] ", nodeName), l.TestResult.NodeLatencies[nodeName])
+		}
+	}
+
+	for _, rateResult := range l.TestResult.RateResults {
+		strData += fmt.Sprintf("\t\t[%s] Transaction Count: %d, "+
+			"Target Rate: %.2f tx/s, Achieved Rate: %.2f tx/s\n",
+			rateResult.Name,
+			rateResult.TxCount,
+			rateResult.TargetRate,
+			rateResult.AchievedRate)
+	}
+
 	err := l.WriteFile([]byte(strData))
 	if err != nil {
 		return err
@@ -115,6 +486,16 @@ func (l *LogClient) WriteTestResults() error {
 	return nil
 }
 
+// formatLatencies returns the summary lines of the given latency histograms,
+// inclusion latency is omitted if nothing is recorded.
+func formatLatencies(prefix string, latencies *LatencyResult) string {
+	strData := fmt.Sprintf("\t\t%sSend Latency: %s\n", prefix, latencies.SendLatency.Summary())
+	if latencies.InclusionLatency.Count() > 0 {
+		strData += fmt.Sprintf("\t\t%sInclusion Latency: %s\n", prefix, latencies.InclusionLatency.Summary())
+	}
+	return strData
+}
+
 func (l *LogClient) CloseFile() error {
 	err := l.LogFile.Close()
 	if err != nil {
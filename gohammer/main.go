@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/urfave/cli.v1"
@@ -25,6 +28,7 @@ var (
 	flags = []cli.Flag{
 		TestProfileConfigFileFlag,
 		TestLogDirFlag,
+		GracePeriodFlag,
 	}
 
 	rpcClient    *rpc.RPCClient
@@ -34,19 +38,20 @@ var (
 	cfg config.Config
 )
 
-func readConfig(cfg *config.Config, configFileName string) {
+func readConfig(cfg *config.Config, configFileName string) error {
 	configFileName, _ = filepath.Abs(configFileName)
 	log.Infof("Loading config: %v", configFileName)
 
 	configFile, err := os.Open(configFileName)
 	if err != nil {
-		log.Fatal("File error: ", err.Error())
+		return fmt.Errorf("File error: %v", err)
 	}
 	defer configFile.Close()
 	jsonParser := json.NewDecoder(configFile)
 	if err := jsonParser.Decode(&cfg); err != nil {
-		log.Fatal("Config error: ", err.Error())
+		return fmt.Errorf("Config error: %v", err)
 	}
+	return nil
 }
 
 func init() {
@@ -64,10 +69,14 @@ func init() {
 	}
 
 	app.After = func(c *cli.Context) error {
-		if loggerClient.LogFile != nil {
-			err := loggerClient.WriteTestResults()
-			if err != nil {
-				log.Errorf("Error while writing to test log file: %v", err)
+		if loggerClient != nil && loggerClient.LogFile != nil {
+			// the test results are written even if the test failed, they
+			// are partial then.
+			if loggerClient.TestResult != nil {
+				err := loggerClient.WriteTestResults()
+				if err != nil {
+					log.Errorf("Error while writing to test log file: %v", err)
+				}
 			}
 			loggerClient.LogFile.Close()
 		}
@@ -90,17 +99,23 @@ func gohammer(ctx *cli.Context) error {
 	var err error
 	loggerClient, err = checkTestLogDirNameFlag(testLogDirName)
 	if err != nil {
-		log.Errorf("Error while creating logger client: %v", err)
+		return fmt.Errorf("Error while creating logger client: %v", err)
 	}
 	deployClient = store.NewDeployClient(loggerClient)
+	deployClient.GracePeriod = ctx.GlobalDuration(GracePeriodFlag.Name)
+	deployClient.RPCClient = rpcClient
 
-	readConfig(&cfg, testProfileFileName)
-	rpcClient.CheckNodes(&cfg)
-
-	testProfiles := cfg.TestProfiles
-	startTest(testProfiles)
+	if err := readConfig(&cfg, testProfileFileName); err != nil {
+		return err
+	}
+	if err := deployClient.LoadSenderKeys(cfg.TestProfiles); err != nil {
+		return err
+	}
+	if err := rpcClient.CheckNodes(&cfg); err != nil {
+		return err
+	}
 
-	return nil
+	return startTest(cfg.TestProfiles, cfg.Concurrent)
 }
 
 func checkTestLogDirNameFlag(testLogDirName string) (*logger.LogClient, error) {
@@ -111,6 +126,9 @@ func checkTestLogDirNameFlag(testLogDirName string) (*logger.LogClient, error) {
 	if testLogDirName != "" {
 		// check if given dir is exists or not
 		testLogAbsDirPath, err := filepath.Abs(testLogDirName)
+		if err != nil {
+			return nil, err
+		}
 		logDirFile, err = logger.CreateLogFile(testLogAbsDirPath, TestResultFilename)
 		if err != nil {
 			return nil, err
@@ -124,8 +142,36 @@ func checkTestLogDirNameFlag(testLogDirName string) (*logger.LogClient, error) {
 	return logger.NewLogClient(logDirFile), nil
 }
 
-func startTest(testProfiles []config.TestProfile) {
-	deployClient.DeployTestProfiles(testProfiles)
+func startTest(testProfiles []config.TestProfile, concurrent bool) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handleInterrupts(cancel)
+
+	return deployClient.DeployTestProfiles(ctx, testProfiles, concurrent)
+}
+
+// handleInterrupts interrupts the test on the first SIGINT or SIGTERM, the
+// partial test results are still written. Further interrupts force the
+// shutdown.
+func handleInterrupts(interrupt context.CancelFunc) {
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(sigc)
+		<-sigc
+		log.Error("Interrupt signal caught, shutting down GoHammer")
+
+		interrupt()
+
+		for i := 5; i > 0; i-- {
+			<-sigc
+			if i > 1 {
+				log.Warning(fmt.Sprintf("Shutdown in progress, interrupt %d more times to force shutdown", i-1))
+			}
+		}
+		log.Error("Forced shutdown: maximum interrupts given")
+		os.Exit(1)
+	}()
 }
 
 func main() {
@@ -1,6 +1,8 @@
 package rpc
 
 import (
+	"errors"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/tubuarge/GoHammer/config"
@@ -8,8 +10,8 @@ import (
 
 // checkNodes calls isNodeUp function to ensure that every node is running
 // before starting the test.
-// If there is a failed node then terminates the GoHammer.
-func (r *RPCClient) CheckNodes(cfg *config.Config) {
+// If there is a failed node then returns an error.
+func (r *RPCClient) CheckNodes(cfg *config.Config) error {
 	isOK := true
 
 	profiles := cfg.TestProfiles
@@ -28,11 +30,13 @@ func (r *RPCClient) CheckNodes(cfg *config.Config) {
 				log.Errorf("%s node is not running.", node.Name)
 				continue
 			}
-			log.Infof("%s node is OK.", node.Name)
+			transport, _ := Transport(node.URL)
+			log.Infof("%s node is OK (%s).", node.Name, transport)
 		}
 	}
 
 	if !isOK {
-		log.Fatalf("Make sure every node given in the test-profile file is running.")
+		return errors.New("Make sure every node given in the test-profile file is running.")
 	}
+	return nil
 }
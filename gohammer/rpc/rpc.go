@@ -1,14 +1,30 @@
 package rpc
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
+	"context"
+	"fmt"
 	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Transports of the node URLs.
+const (
+	TransportHTTP      = "http"
+	TransportWebSocket = "ws"
+	TransportIPC       = "ipc"
 )
 
+// DefaultTimeout is the timeout of a request, including dialing the node.
+const DefaultTimeout = 10 * time.Second
+
 type RPCClient struct {
+	// Client is used by the HTTP transport.
 	Client *http.Client
+
+	Timeout time.Duration
 }
 
 func NewRPCClient() *RPCClient {
@@ -16,63 +32,70 @@ func NewRPCClient() *RPCClient {
 	httpClient := &http.Client{}
 
 	rpcClient.Client = httpClient
+	rpcClient.Timeout = DefaultTimeout
 
 	return rpcClient
 }
 
-type JSONRPCResp struct {
-	ID     *json.RawMessage       `json:"id"`
-	Result *json.RawMessage       `json:"result"`
-	Error  map[string]interface{} `json:"error"`
-}
-
-// IsNodeUp sends a `web3_clientVersion` RPC request to the given node.
-// If RPC response is not nil and there is no error returns true otherwise
-// returns false.
-func (r *RPCClient) IsNodeUp(nodeUrl string) (bool, error) {
-	resp, err := r.doPost(nodeUrl, "web3_clientVersion", nil)
+// Transport returns the transport of the given node URL. http(s):// URLs
+// are HTTP, ws(s):// URLs are WebSocket and anything without a scheme is
+// the path of a Unix socket (IPC), like the URLs go-ethereum dials.
+func Transport(nodeUrl string) (string, error) {
+	u, err := url.Parse(nodeUrl)
 	if err != nil {
-		return false, err
+		return "", err
 	}
-
-	if resp.Result != nil {
-		return true, nil
+	switch u.Scheme {
+	case "http", "https":
+		return TransportHTTP, nil
+	case "ws", "wss":
+		return TransportWebSocket, nil
+	case "":
+		return TransportIPC, nil
 	}
-	return false, nil
+	return "", fmt.Errorf("unsupported transport: %q", u.Scheme)
 }
 
-func (r *RPCClient) doPost(url, method string, params interface{}) (*JSONRPCResp, error) {
-	jsonReq := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"method":  method,
-		"params":  params,
-		"id":      0,
+// Dial connects to the given node with the transport of its URL.
+func (r *RPCClient) Dial(ctx context.Context, nodeUrl string) (*rpc.Client, error) {
+	transport, err := Transport(nodeUrl)
+	if err != nil {
+		return nil, err
 	}
 
-	data, _ := json.Marshal(jsonReq)
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
-	req.Header.Set("Content-Length", string(len(data)))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+	switch transport {
+	case TransportHTTP:
+		return rpc.DialHTTPWithClient(nodeUrl, r.Client)
+	case TransportWebSocket:
+		return rpc.DialWebsocket(ctx, nodeUrl, "")
+	default:
+		return rpc.DialIPC(ctx, nodeUrl)
+	}
+}
 
-	httpClient := &http.Client{}
+// Call sends an RPC request to the given node and stores its result into
+// result.
+func (r *RPCClient) Call(nodeUrl string, result interface{}, method string, params ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.Timeout)
+	defer cancel()
 
-	resp, err := httpClient.Do(req)
+	client, err := r.Dial(ctx, nodeUrl)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer resp.Body.Close()
+	defer client.Close()
 
-	var rpcResp *JSONRPCResp
-	err = json.NewDecoder(resp.Body).Decode(&rpcResp)
-	if err != nil {
-		return nil, err
-	}
+	return client.CallContext(ctx, result, method, params...)
+}
 
-	if rpcResp.Error != nil {
-		return nil, errors.New(rpcResp.Error["message"].(string))
+// IsNodeUp sends a `web3_clientVersion` RPC request to the given node.
+// If RPC response is not empty and there is no error returns true otherwise
+// returns false.
+func (r *RPCClient) IsNodeUp(nodeUrl string) (bool, error) {
+	var clientVersion string
+	if err := r.Call(nodeUrl, &clientVersion, "web3_clientVersion"); err != nil {
+		return false, err
 	}
 
-	return rpcResp, err
+	return clientVersion != "", nil
 }
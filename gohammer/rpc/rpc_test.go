@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"net"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type web3API struct{}
+
+func (web3API) ClientVersion() string {
+	return "Geth/v1.10.8"
+}
+
+func newWeb3Server(t *testing.T) *rpc.Server {
+	server := rpc.NewServer()
+	if err := server.RegisterName("web3", web3API{}); err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		url       string
+		transport string
+	}{
+		{"http://localhost:8545", TransportHTTP},
+		{"https://node.example.com", TransportHTTP},
+		{"ws://localhost:8546", TransportWebSocket},
+		{"wss://node.example.com", TransportWebSocket},
+		{"/data/geth.ipc", TransportIPC},
+		{"geth.ipc", TransportIPC},
+	}
+	for _, test := range tests {
+		transport, err := Transport(test.url)
+		if err != nil || transport != test.transport {
+			t.Errorf("Transport(%q) = %q, %v, want %q", test.url, transport, err, test.transport)
+		}
+	}
+
+	if _, err := Transport("ftp://localhost"); err == nil {
+		t.Error("Transport didn't fail on an unsupported scheme")
+	}
+}
+
+func TestIsNodeUp(t *testing.T) {
+	server := newWeb3Server(t)
+	defer server.Stop()
+
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	wsServer := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer wsServer.Close()
+
+	ipcPath := filepath.Join(t.TempDir(), "geth.ipc")
+	listener, err := net.Listen("unix", ipcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go server.ServeListener(listener)
+
+	urls := []string{
+		httpServer.URL,
+		"ws" + strings.TrimPrefix(wsServer.URL, "http"),
+		ipcPath,
+	}
+	client := NewRPCClient()
+	for _, url := range urls {
+		isNodeUp, err := client.IsNodeUp(url)
+		if err != nil || !isNodeUp {
+			t.Errorf("IsNodeUp(%q) = %v, %v, want true", url, isNodeUp, err)
+		}
+	}
+
+	if isNodeUp, err := client.IsNodeUp(filepath.Join(t.TempDir(), "missing.ipc")); err == nil || isNodeUp {
+		t.Error("IsNodeUp didn't fail on a missing node")
+	}
+}
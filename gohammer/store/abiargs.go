@@ -0,0 +1,224 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var bigIntType = reflect.TypeOf(&big.Int{})
+
+// contractArgs generates the arguments of a contract constructor or method
+// for every transaction.
+type contractArgs struct {
+	arguments  abi.Arguments
+	generators []argGenerator
+}
+
+// newContractArgs returns the contract arguments of the given config values.
+// Constant values are converted once here, generated ones for every
+// transaction.
+func newContractArgs(arguments abi.Arguments, values []interface{}, random *lockedRand) (*contractArgs, error) {
+	if len(arguments) != len(values) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", len(arguments), len(values))
+	}
+
+	args := &contractArgs{arguments: arguments}
+	for i, value := range values {
+		generator, err := newArgGenerator(value, random)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", i, err)
+		}
+		if constant, ok := generator.(constGenerator); ok {
+			converted, err := convertArg(arguments[i].Type, constant.value)
+			if err != nil {
+				return nil, fmt.Errorf("argument %d (%s): %v", i, arguments[i].Type, err)
+			}
+			generator = constGenerator{converted}
+		}
+		args.generators = append(args.generators, generator)
+	}
+	return args, nil
+}
+
+// generate returns the arguments of a transaction.
+func (a *contractArgs) generate(ctx *argContext) ([]interface{}, error) {
+	var values []interface{}
+	for i, generator := range a.generators {
+		value, err := generator.generate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := generator.(constGenerator); !ok {
+			value, err = convertArg(a.arguments[i].Type, value)
+			if err != nil {
+				return nil, fmt.Errorf("generated argument %d (%s): %v", i, a.arguments[i].Type, err)
+			}
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// convertArg converts a JSON decoded or generated value into the Go type of
+// the given ABI type. Numbers can be given as JSON numbers or decimal/hex
+// strings, bytes and addresses as hex strings. Generated integers are
+// converted to big-endian bytes and decimal strings for bytes and string
+// arguments.
+func convertArg(abiType abi.Type, value interface{}) (interface{}, error) {
+	if n, ok := value.(*big.Int); ok {
+		switch abiType.T {
+		case abi.StringTy:
+			return n.String(), nil
+		case abi.BytesTy:
+			return n.Bytes(), nil
+		case abi.FixedBytesTy:
+			if n.Sign() < 0 || len(n.Bytes()) > abiType.Size {
+				return nil, fmt.Errorf("%s doesn't fit in bytes%d", n, abiType.Size)
+			}
+			value = common.LeftPadBytes(n.Bytes(), abiType.Size)
+		}
+	}
+	if v, ok := value.(interface{ Bytes() []byte }); ok && (abiType.T == abi.BytesTy || abiType.T == abi.FixedBytesTy) {
+		value = v.Bytes()
+	}
+
+	switch abiType.T {
+	case abi.BoolTy:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+
+	case abi.StringTy:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case fmt.Stringer:
+			return v.String(), nil
+		}
+
+	case abi.AddressTy:
+		if v, ok := value.(string); ok && common.IsHexAddress(v) {
+			return common.HexToAddress(v), nil
+		}
+
+	case abi.IntTy, abi.UintTy:
+		n, err := toBigInt(value)
+		if err != nil {
+			return nil, err
+		}
+		if !fitsIntType(n, abiType) {
+			return nil, fmt.Errorf("%s is out of range", n)
+		}
+		goType := abiType.GetType()
+		if goType == bigIntType {
+			return n, nil
+		}
+		if abiType.T == abi.UintTy {
+			return reflect.ValueOf(n.Uint64()).Convert(goType).Interface(), nil
+		}
+		return reflect.ValueOf(n.Int64()).Convert(goType).Interface(), nil
+
+	case abi.BytesTy:
+		switch v := value.(type) {
+		case []byte:
+			return v, nil
+		case string:
+			return hexutil.Decode(v)
+		}
+
+	case abi.FixedBytesTy:
+		var data []byte
+		switch v := value.(type) {
+		case []byte:
+			data = v
+		case string:
+			var err error
+			data, err = hexutil.Decode(v)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("can't convert %v (%T) to %s", value, value, abiType)
+		}
+		if len(data) > abiType.Size {
+			return nil, fmt.Errorf("%d bytes don't fit in bytes%d", len(data), abiType.Size)
+		}
+		array := reflect.New(abiType.GetType()).Elem()
+		reflect.Copy(array, reflect.ValueOf(data))
+		return array.Interface(), nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		values, ok := value.([]interface{})
+		if !ok {
+			break
+		}
+		if abiType.T == abi.ArrayTy && len(values) != abiType.Size {
+			return nil, fmt.Errorf("expected %d elements, got %d", abiType.Size, len(values))
+		}
+
+		var result reflect.Value
+		if abiType.T == abi.ArrayTy {
+			result = reflect.New(abiType.GetType()).Elem()
+		} else {
+			result = reflect.MakeSlice(abiType.GetType(), len(values), len(values))
+		}
+		for i, elem := range values {
+			converted, err := convertArg(*abiType.Elem, elem)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %v", i, err)
+			}
+			result.Index(i).Set(reflect.ValueOf(converted))
+		}
+		return result.Interface(), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported type %s", abiType)
+	}
+
+	return nil, fmt.Errorf("can't convert %v (%T) to %s", value, value, abiType)
+}
+
+// fitsIntType reports whether n is in the range of the given int or uint
+// ABI type.
+func fitsIntType(n *big.Int, abiType abi.Type) bool {
+	if abiType.T == abi.UintTy {
+		return n.Sign() >= 0 && n.BitLen() <= abiType.Size
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(abiType.Size-1))
+	return n.Cmp(new(big.Int).Neg(limit)) >= 0 && n.Cmp(limit) < 0
+}
+
+// toBigInt converts a JSON number or a decimal/hex string into a big integer.
+func toBigInt(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		return v, nil
+	case float64:
+		if v != math.Trunc(v) {
+			return nil, fmt.Errorf("%v is not an integer", v)
+		}
+		n, _ := big.NewFloat(v).Int(nil)
+		return n, nil
+	case json.Number:
+		return toBigInt(v.String())
+	case string:
+		n, ok := new(big.Int).SetString(strings.TrimSpace(v), 0)
+		if !ok {
+			return nil, fmt.Errorf("%q is not an integer", v)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("can't convert %v (%T) to integer", value, value)
+}
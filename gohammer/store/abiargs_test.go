@@ -0,0 +1,79 @@
+package store
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const testArgsABI = `[{"type":"function","name":"f","inputs":[
+	{"name":"a","type":"uint256"},
+	{"name":"b","type":"uint8"},
+	{"name":"c","type":"int64"},
+	{"name":"d","type":"address"},
+	{"name":"e","type":"bytes32"},
+	{"name":"f","type":"bytes"},
+	{"name":"g","type":"bool"},
+	{"name":"h","type":"string"},
+	{"name":"i","type":"uint16[]"}
+]}]`
+
+func TestContractArgsConvert(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(testArgsABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := parsed.Methods["f"].Inputs
+
+	values := []interface{}{
+		"0x10",
+		float64(7),
+		"-5",
+		"0x00000000000000000000000000000000000000aa",
+		"0x01",
+		"0xbeef",
+		true,
+		"hello",
+		[]interface{}{float64(1), "2"},
+	}
+
+	contractArgs, err := newContractArgs(inputs, values, newLockedRand(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	args, err := contractArgs.generate(&argContext{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []interface{}{
+		big.NewInt(16),
+		uint8(7),
+		int64(-5),
+		common.HexToAddress("0xaa"),
+		[32]byte{1},
+		[]byte{0xbe, 0xef},
+		true,
+		"hello",
+		[]uint16{1, 2},
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("generated arguments = %v, want %v", args, want)
+	}
+
+	if _, err := parsed.Pack("f", args...); err != nil {
+		t.Errorf("converted arguments can't be packed: %v", err)
+	}
+
+	values[1] = float64(300)
+	if _, err := newContractArgs(inputs, values, newLockedRand(0)); err == nil {
+		t.Errorf("expected out of range error for uint8")
+	}
+	if _, err := newContractArgs(inputs, values[:2], newLockedRand(0)); err == nil {
+		t.Errorf("expected argument count error")
+	}
+}
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+// sender is an account that the transactions of a node are sent from.
+type sender struct {
+	signer  Signer
+	address common.Address
+	nonces  *NonceManager
+}
+
+// LoadSenderKeys loads the sender accounts of every node of the given test
+// profiles, so keystores are decrypted once before the test starts and
+// invalid credentials are reported before any transaction is sent.
+func (d *DeployClient) LoadSenderKeys(testProfiles []config.TestProfile) error {
+	for i := range testProfiles {
+		for j := range testProfiles[i].Nodes {
+			nodeConfig := &testProfiles[i].Nodes[j]
+			if nodeConfig.Signer != nil {
+				// the keys of the node are held by its external signer.
+				continue
+			}
+			if _, err := d.getSenderKeys(nodeConfig); err != nil {
+				return fmt.Errorf("Error while loading sender accounts of [%s] node of %s profile: %v",
+					nodeConfig.Name, testProfiles[i].Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// getSenderKeys returns the private keys of the sender accounts of the given
+// node. They are loaded once per credential config, so nodes and test
+// profiles with the same credentials share them.
+func (d *DeployClient) getSenderKeys(nodeConfig *config.NodeConfig) ([]*ecdsa.PrivateKey, error) {
+	source, err := json.Marshal(struct {
+		Cipher, Keystore, PasswordFile, PasswordEnv string
+		Senders                                     *config.SenderPool
+	}{nodeConfig.Cipher, nodeConfig.Keystore, nodeConfig.PasswordFile, nodeConfig.PasswordEnv, nodeConfig.Senders})
+	if err != nil {
+		return nil, err
+	}
+
+	d.senderKeysMu.Lock()
+	defer d.senderKeysMu.Unlock()
+
+	keys, ok := d.senderKeys[string(source)]
+	if !ok {
+		keys, err = loadSenderKeys(nodeConfig)
+		if err != nil {
+			return nil, err
+		}
+		d.senderKeys[string(source)] = keys
+	}
+	return keys, nil
+}
+
+// loadSenderKeys returns the private keys of the sender accounts of the
+// given node. If the node has a sender pool its accounts are used, otherwise
+// the node cipher or keystore is used.
+func loadSenderKeys(nodeConfig *config.NodeConfig) ([]*ecdsa.PrivateKey, error) {
+	pool := nodeConfig.Senders
+	if pool == nil {
+		privateKey, err := loadNodeKey(nodeConfig)
+		if err != nil {
+			return nil, err
+		}
+		return []*ecdsa.PrivateKey{privateKey}, nil
+	}
+
+	switch {
+	case pool.Mnemonic != "" && pool.KeystoreDir != "":
+		return nil, errors.New("sender pool can't have both mnemonic and keystoreDir")
+	case pool.Mnemonic != "":
+		return deriveMnemonicKeys(pool.Mnemonic, pool.Passphrase, pool.DerivationPath, pool.Count)
+	case pool.KeystoreDir != "":
+		return loadKeystoreKeys(pool.KeystoreDir, pool.PasswordFile, pool.PasswordEnv, pool.Count)
+	default:
+		return nil, errors.New("sender pool must have a mnemonic or a keystoreDir")
+	}
+}
+
+// loadNodeKey returns the private key of a node without a sender pool, it
+// is either the hex cipher or decrypted from the keystore file.
+func loadNodeKey(nodeConfig *config.NodeConfig) (*ecdsa.PrivateKey, error) {
+	switch {
+	case nodeConfig.Cipher != "" && nodeConfig.Keystore != "":
+		return nil, errors.New("node can't have both cipher and keystore")
+	case nodeConfig.Cipher != "":
+		// the error of HexToECDSA is not returned as it may contain a part
+		// of the key.
+		privateKey, err := crypto.HexToECDSA(nodeConfig.Cipher)
+		if err != nil {
+			return nil, errors.New("cipher is not a valid hex private key")
+		}
+		return privateKey, nil
+	case nodeConfig.Keystore != "":
+		password, err := readPassword(nodeConfig.PasswordFile, nodeConfig.PasswordEnv)
+		if err != nil {
+			return nil, err
+		}
+		keyJSON, err := ioutil.ReadFile(nodeConfig.Keystore)
+		if err != nil {
+			return nil, fmt.Errorf("Error while reading keystore file: %v", err)
+		}
+		key, err := keystore.DecryptKey(keyJSON, password)
+		if err != nil {
+			return nil, fmt.Errorf("Error while decrypting keystore file %s: %v", nodeConfig.Keystore, err)
+		}
+		return key.PrivateKey, nil
+	default:
+		return nil, errors.New("node must have a cipher, a keystore, senders or a signer")
+	}
+}
+
+// readPassword returns the keystore password from the given password file or
+// environment variable, only one of them can be set.
+func readPassword(passwordFile, passwordEnv string) (string, error) {
+	switch {
+	case passwordFile != "" && passwordEnv != "":
+		return "", errors.New("keystore can't have both passwordFile and passwordEnv")
+	case passwordEnv != "":
+		password, ok := os.LookupEnv(passwordEnv)
+		if !ok {
+			return "", fmt.Errorf("keystore password environment variable %s is not set", passwordEnv)
+		}
+		return password, nil
+	case passwordFile != "":
+		password, err := readPasswordFile(passwordFile)
+		if err != nil {
+			return "", fmt.Errorf("Error while reading password file: %v", err)
+		}
+		return password, nil
+	default:
+		return "", errors.New("keystore must have a passwordFile or a passwordEnv")
+	}
+}
+
+// loadKeystoreKeys decrypts the keystore files in the given directory with
+// the password read from passwordFile or the passwordEnv environment
+// variable. If count is greater than zero, at most count keys are loaded.
+func loadKeystoreKeys(dir, passwordFile, passwordEnv string, count int) ([]*ecdsa.PrivateKey, error) {
+	password, err := readPassword(passwordFile, passwordEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+
+	var keys []*ecdsa.PrivateKey
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		if count > 0 && len(keys) == count {
+			break
+		}
+
+		keyJSON, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		key, err := keystore.DecryptKey(keyJSON, password)
+		if err != nil {
+			return nil, fmt.Errorf("Error while decrypting keystore file %s: %v", file.Name(), err)
+		}
+		keys = append(keys, key.PrivateKey)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keystore files found in %s", dir)
+	}
+	return keys, nil
+}
+
+// readPasswordFile returns the first line of the given file.
+func readPasswordFile(passwordFile string) (string, error) {
+	data, err := ioutil.ReadFile(passwordFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(strings.SplitN(string(data), "\n", 2)[0], "\r"), nil
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+func TestLoadNodeKeystore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gohammer-keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	account, err := keystore.StoreKey(dir, "secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keystorePath := account.URL.Path
+	passwordPath := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(passwordPath, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("GOHAMMER_TEST_PASSWORD", "secret")
+	defer os.Unsetenv("GOHAMMER_TEST_PASSWORD")
+
+	valid := []*config.NodeConfig{
+		{Keystore: keystorePath, PasswordFile: passwordPath},
+		{Keystore: keystorePath, PasswordEnv: "GOHAMMER_TEST_PASSWORD"},
+	}
+	for _, nodeConfig := range valid {
+		key, err := loadNodeKey(nodeConfig)
+		if err != nil {
+			t.Errorf("loadNodeKey(%+v): %v", nodeConfig, err)
+			continue
+		}
+		if crypto.PubkeyToAddress(key.PublicKey) != account.Address {
+			t.Errorf("loadNodeKey(%+v) decrypted a different key", nodeConfig)
+		}
+	}
+
+	invalid := []*config.NodeConfig{
+		{},
+		{Cipher: "zz"},
+		{Cipher: "01", Keystore: keystorePath, PasswordFile: passwordPath},
+		{Keystore: keystorePath},
+		{Keystore: keystorePath, PasswordFile: passwordPath, PasswordEnv: "GOHAMMER_TEST_PASSWORD"},
+		{Keystore: keystorePath, PasswordEnv: "GOHAMMER_TEST_UNSET_PASSWORD"},
+		{Keystore: filepath.Join(dir, "missing.json"), PasswordFile: passwordPath},
+	}
+	for _, nodeConfig := range invalid {
+		if _, err := loadNodeKey(nodeConfig); err == nil {
+			t.Errorf("loadNodeKey(%+v) didn't fail", nodeConfig)
+		}
+	}
+
+	os.Setenv("GOHAMMER_TEST_PASSWORD", "wrong")
+	if _, err := loadNodeKey(valid[1]); err == nil {
+		t.Error("loadNodeKey didn't fail with a wrong password")
+	}
+}
+
+func TestGetSenderKeysCached(t *testing.T) {
+	d := NewDeployClient(nil)
+	cipher := "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+
+	first, err := d.getSenderKeys(&config.NodeConfig{Name: "node1", Cipher: cipher})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := d.getSenderKeys(&config.NodeConfig{Name: "node2", Cipher: cipher})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first[0] != second[0] {
+		t.Error("nodes with the same cipher loaded the key twice")
+	}
+}
+
+func TestLoadKeystoreKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gohammer-keystore-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// keystore directory with two accounts, a hidden file and a
+	// subdirectory that are skipped.
+	keystoreDir := filepath.Join(dir, "keystore")
+	var want []string
+	for i := 0; i < 2; i++ {
+		account, err := keystore.StoreKey(keystoreDir, "secret", keystore.LightScryptN, keystore.LightScryptP)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, filepath.Base(account.URL.Path))
+	}
+	if err := ioutil.WriteFile(filepath.Join(keystoreDir, ".hidden"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(keystoreDir, "backup"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	passwordPath := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(passwordPath, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("GOHAMMER_TEST_PASSWORD", "secret")
+	defer os.Unsetenv("GOHAMMER_TEST_PASSWORD")
+
+	tests := []struct {
+		name         string
+		passwordFile string
+		passwordEnv  string
+		count        int
+		want         int
+	}{
+		{"password file", passwordPath, "", 0, 2},
+		{"password env", "", "GOHAMMER_TEST_PASSWORD", 0, 2},
+		{"count", passwordPath, "", 1, 1},
+	}
+	for _, test := range tests {
+		keys, err := loadKeystoreKeys(keystoreDir, test.passwordFile, test.passwordEnv, test.count)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if len(keys) != test.want {
+			t.Errorf("%s: loaded %d keys, want %d", test.name, len(keys), test.want)
+			continue
+		}
+		// keystore files are loaded in name order.
+		for i, key := range keys {
+			address := crypto.PubkeyToAddress(key.PublicKey)
+			if !strings.HasSuffix(strings.ToLower(want[i]), strings.ToLower(address.Hex()[2:])) {
+				t.Errorf("%s: key %d is %s, want the key of %s", test.name, i, address.Hex(), want[i])
+			}
+		}
+	}
+
+	invalid := []struct {
+		name, dir, passwordFile, passwordEnv string
+	}{
+		{"no password", keystoreDir, "", ""},
+		{"both passwords", keystoreDir, passwordPath, "GOHAMMER_TEST_PASSWORD"},
+		{"unset env", keystoreDir, "", "GOHAMMER_TEST_UNSET_PASSWORD"},
+		{"missing dir", filepath.Join(dir, "missing"), passwordPath, ""},
+		{"empty dir", filepath.Join(keystoreDir, "backup"), passwordPath, ""},
+	}
+	for _, test := range invalid {
+		if _, err := loadKeystoreKeys(test.dir, test.passwordFile, test.passwordEnv, 0); err == nil {
+			t.Errorf("%s: loadKeystoreKeys didn't fail", test.name)
+		}
+	}
+
+	os.Setenv("GOHAMMER_TEST_PASSWORD", "wrong")
+	if _, err := loadKeystoreKeys(keystoreDir, "", "GOHAMMER_TEST_PASSWORD", 0); err == nil {
+		t.Error("loadKeystoreKeys didn't fail with a wrong password")
+	}
+}
@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/logger"
+	"github.com/tubuarge/GoHammer/util"
+)
+
+const DefaultBatchFlushInterval = 10 * time.Millisecond
+
+// batchBackend sends transactions in JSON-RPC batch requests. Concurrent
+// SendTransaction calls are collected into a batch that is sent when it has
+// size transactions or flushInterval after its first transaction, and every
+// call returns the error of its own transaction.
+type batchBackend struct {
+	rpcClient *rpc.Client
+	results   *logger.TestResults
+
+	// method is called with the raw transaction and extraArgs for every
+	// transaction of a batch.
+	method    string
+	extraArgs []interface{}
+
+	size          int
+	flushInterval time.Duration
+
+	mu      sync.Mutex
+	pending []*batchItem
+	// pendingCtx is the context of the first transaction of the pending
+	// batch, the batch request is sent with it.
+	pendingCtx context.Context
+	timer      *time.Timer
+	// batchID identifies the pending batch, so the flush timer of a batch
+	// that is already sent doesn't send the next one early.
+	batchID uint64
+}
+
+// unthrottledConcurrency returns how many transactions of the given test
+// profile are sent at a time when it has no rate. A batched test profile
+// sends a full batch at a time, otherwise every transaction would wait
+// alone for the flush interval of its batch.
+func unthrottledConcurrency(testProfile *config.TestProfile) int {
+	if testProfile.Batch != nil && testProfile.Batch.Size > 1 {
+		return testProfile.Batch.Size
+	}
+	return 1
+}
+
+// batchItem is a transaction of a batch, done is closed when the batch is
+// sent.
+type batchItem struct {
+	elem rpc.BatchElem
+	done chan struct{}
+}
+
+func newBatchBackend(rpcClient *rpc.Client, batchConfig *config.BatchConfig, results *logger.TestResults,
+	method string, extraArgs ...interface{}) (*batchBackend, error) {
+	if batchConfig.Size <= 0 {
+		return nil, fmt.Errorf("batch size has to be positive: %d", batchConfig.Size)
+	}
+
+	flushInterval := DefaultBatchFlushInterval
+	if batchConfig.FlushInterval != "" {
+		var err error
+		flushInterval, err = util.ParseDuration(batchConfig.FlushInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flush interval: %v", err)
+		}
+		if flushInterval <= 0 {
+			return nil, errors.New("flush interval has to be positive")
+		}
+	}
+
+	return &batchBackend{
+		rpcClient:     rpcClient,
+		results:       results,
+		method:        method,
+		extraArgs:     extraArgs,
+		size:          batchConfig.Size,
+		flushInterval: flushInterval,
+	}, nil
+}
+
+func (b *batchBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	item := &batchItem{
+		elem: rpc.BatchElem{
+			Method: b.method,
+			Args:   append([]interface{}{hexutil.Encode(data)}, b.extraArgs...),
+			Result: new(common.Hash),
+		},
+		done: make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, item)
+	var batch []*batchItem
+	var batchCtx context.Context
+	if len(b.pending) == 1 {
+		b.pendingCtx = ctx
+	}
+	if len(b.pending) >= b.size {
+		batch, batchCtx = b.takeBatch()
+	} else if len(b.pending) == 1 {
+		batchID := b.batchID
+		b.timer = time.AfterFunc(b.flushInterval, func() {
+			b.flush(batchID)
+		})
+	}
+	b.mu.Unlock()
+
+	// a full batch is sent by the transaction that fills it.
+	if batch != nil {
+		b.send(batchCtx, batch)
+	}
+
+	select {
+	case <-item.done:
+		return item.elem.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// takeBatch returns the pending batch with its context and starts a new
+// one, b.mu has to be held.
+func (b *batchBackend) takeBatch() ([]*batchItem, context.Context) {
+	batch, ctx := b.pending, b.pendingCtx
+	b.pending, b.pendingCtx = nil, nil
+	b.batchID++
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return batch, ctx
+}
+
+// flush sends the pending batch if it is still the batch with the given id.
+func (b *batchBackend) flush(batchID uint64) {
+	b.mu.Lock()
+	if b.batchID != batchID || len(b.pending) == 0 {
+		b.mu.Unlock()
+		return
+	}
+	batch, ctx := b.takeBatch()
+	b.mu.Unlock()
+
+	b.send(ctx, batch)
+}
+
+// send sends the given batch in one request with the given context. If the
+// request fails, every transaction of the batch fails with its error.
+func (b *batchBackend) send(ctx context.Context, batch []*batchItem) {
+	elems := make([]rpc.BatchElem, len(batch))
+	for i, item := range batch {
+		elems[i] = item.elem
+	}
+
+	err := b.rpcClient.BatchCallContext(ctx, elems)
+	b.results.AddBatch(len(batch))
+	for i, item := range batch {
+		item.elem.Error = elems[i].Error
+		if err != nil {
+			item.elem.Error = err
+		}
+		close(item.done)
+	}
+}
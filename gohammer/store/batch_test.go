@@ -0,0 +1,159 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/logger"
+)
+
+// mockEth is the eth API of a mock node, it rejects the transactions with
+// an odd nonce.
+type mockEth struct{}
+
+func (mockEth) SendRawTransaction(data hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return common.Hash{}, err
+	}
+	if tx.Nonce()%2 == 1 {
+		return common.Hash{}, errors.New("nonce too low")
+	}
+	return tx.Hash(), nil
+}
+
+func newMockEthClient(t *testing.T) (*rpc.Client, func()) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", mockEth{}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+
+	client, err := rpc.DialHTTP(httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, func() {
+		client.Close()
+		httpServer.Close()
+		server.Stop()
+	}
+}
+
+func TestBatchBackend(t *testing.T) {
+	client, stop := newMockEthClient(t)
+	defer stop()
+
+	results := &logger.TestResults{}
+	backend, err := newBatchBackend(client, &config.BatchConfig{Size: 4, FlushInterval: "1h"}, results, "eth_sendRawTransaction")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 8 transactions fill 2 batches, so they are sent before the flush
+	// interval.
+	errs := make([]error, 8)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(nonce int) {
+			defer wg.Done()
+			tx := types.NewTx(&types.LegacyTx{Nonce: uint64(nonce), Gas: 21000, GasPrice: big.NewInt(1)})
+			errs[nonce] = backend.SendTransaction(context.Background(), tx)
+		}(i)
+	}
+	wg.Wait()
+
+	for nonce, err := range errs {
+		if (nonce%2 == 1) != (err != nil) {
+			t.Errorf("nonce %d: error = %v", nonce, err)
+		}
+	}
+	if results.BatchCount != 2 || results.BatchedTxCount != 8 {
+		t.Errorf("batches: %d, batched transactions: %d, want 2 and 8", results.BatchCount, results.BatchedTxCount)
+	}
+}
+
+func TestBatchBackendFlushInterval(t *testing.T) {
+	client, stop := newMockEthClient(t)
+	defer stop()
+
+	results := &logger.TestResults{}
+	backend, err := newBatchBackend(client, &config.BatchConfig{Size: 100, FlushInterval: "10ms"}, results, "eth_sendRawTransaction")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	tx := types.NewTx(&types.LegacyTx{Gas: 21000, GasPrice: big.NewInt(1)})
+	if err := backend.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("batch is sent after %s", elapsed)
+	}
+	if results.BatchCount != 1 || results.BatchedTxCount != 1 {
+		t.Errorf("batches: %d, batched transactions: %d, want 1 and 1", results.BatchCount, results.BatchedTxCount)
+	}
+}
+
+func TestSendTxsUnthrottledFillsBatches(t *testing.T) {
+	client, stop := newMockEthClient(t)
+	defer stop()
+
+	results := &logger.TestResults{}
+	batchConfig := &config.BatchConfig{Size: 4, FlushInterval: "1h"}
+	backend, err := newBatchBackend(client, batchConfig, results, "eth_sendRawTransaction")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without a rate the transactions would wait for the flush interval one
+	// by one.
+	d := NewDeployClient(logger.NewLogClient(nil))
+	concurrency := unthrottledConcurrency(&config.TestProfile{Batch: batchConfig})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.sendTxs(context.Background(), "test", 0, concurrency, 8, func(i int) {
+			tx := types.NewTx(&types.LegacyTx{Nonce: uint64(2 * i), Gas: 21000, GasPrice: big.NewInt(1)})
+			if err := backend.SendTransaction(context.Background(), tx); err != nil {
+				t.Errorf("transaction %d failed: %v", i, err)
+			}
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("unthrottled transactions wait for the flush interval")
+	}
+	if results.BatchCount != 2 || results.BatchedTxCount != 8 {
+		t.Errorf("batches: %d, batched transactions: %d, want 2 and 8", results.BatchCount, results.BatchedTxCount)
+	}
+}
+
+func TestNewBatchBackendInvalid(t *testing.T) {
+	invalid := []*config.BatchConfig{
+		{},
+		{Size: -1},
+		{Size: 10, FlushInterval: "later"},
+		{Size: 10, FlushInterval: "0s"},
+	}
+	for _, batchConfig := range invalid {
+		if _, err := newBatchBackend(nil, batchConfig, nil, "eth_sendRawTransaction"); err == nil {
+			t.Errorf("newBatchBackend(%+v) didn't fail", batchConfig)
+		}
+	}
+}
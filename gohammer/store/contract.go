@@ -0,0 +1,181 @@
+package store
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+// Contract is a contract workload. It is deployed from its ABI and bytecode
+// and its method is called by packing the arguments with the ABI, so any
+// contract can be used without generating Go bindings. Its transactions are
+// built by txOpts.signTx instead of bind.BoundContract, see txOpts.
+type Contract struct {
+	// txIndex is the index of the next transaction given to the argument
+	// generators, it is accessed atomically.
+	txIndex uint64
+
+	Name     string
+	ABI      abi.ABI
+	Bytecode []byte
+
+	Method string
+
+	// Address is the address of an already deployed contract, if it is set
+	// the method is called on it instead of a newly deployed contract.
+	Address *common.Address
+
+	constructorArgs *contractArgs
+	methodArgs      *contractArgs
+}
+
+// NewStoreContract returns the built-in Store contract workload, it is
+// deployed with version "1.0" and its setItem method is called.
+func NewStoreContract() (*Contract, error) {
+	parsed, err := abi.JSON(strings.NewReader(StoreABI))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Contract{
+		Name:     "Store",
+		ABI:      parsed,
+		Bytecode: common.FromHex(StoreBin),
+		Method:   "setItem",
+		constructorArgs: &contractArgs{
+			arguments:  parsed.Constructor.Inputs,
+			generators: []argGenerator{constGenerator{"1.0"}},
+		},
+		methodArgs: &contractArgs{
+			arguments:  parsed.Methods["setItem"].Inputs,
+			generators: []argGenerator{constGenerator{[32]byte{1}}, constGenerator{[32]byte{2}}},
+		},
+	}, nil
+}
+
+// LoadContract returns the contract workload of the given test profile, if
+// the test profile has no contract the built-in Store contract is used.
+func LoadContract(contractConfig *config.ContractConfig) (*Contract, error) {
+	if contractConfig == nil {
+		return NewStoreContract()
+	}
+
+	abiFile, err := os.Open(contractConfig.ABIFile)
+	if err != nil {
+		return nil, fmt.Errorf("Error while opening contract ABI: %v", err)
+	}
+	defer abiFile.Close()
+
+	parsed, err := abi.JSON(abiFile)
+	if err != nil {
+		return nil, fmt.Errorf("Error while parsing contract ABI: %v", err)
+	}
+
+	contract := &Contract{
+		Name:   contractConfig.Name,
+		ABI:    parsed,
+		Method: contractConfig.Method,
+	}
+	if contract.Name == "" {
+		contract.Name = contractConfig.ABIFile
+	}
+
+	if contractConfig.Address != "" {
+		if !common.IsHexAddress(contractConfig.Address) {
+			return nil, fmt.Errorf("invalid contract address: %q", contractConfig.Address)
+		}
+		address := common.HexToAddress(contractConfig.Address)
+		contract.Address = &address
+	}
+
+	if contractConfig.BinFile != "" {
+		bin, err := ioutil.ReadFile(contractConfig.BinFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error while reading contract bytecode: %v", err)
+		}
+		contract.Bytecode, err = hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(bin)), "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("Error while decoding contract bytecode: %v", err)
+		}
+	} else if contract.Address == nil {
+		return nil, errors.New("contract must have a bytecode file or an address")
+	}
+
+	random := newLockedRand(contractConfig.Seed)
+	contract.constructorArgs, err = newContractArgs(parsed.Constructor.Inputs, contractConfig.ConstructorArgs, random)
+	if err != nil {
+		return nil, fmt.Errorf("invalid constructor arguments: %v", err)
+	}
+
+	if contract.Method != "" {
+		method, ok := parsed.Methods[contract.Method]
+		if !ok {
+			return nil, fmt.Errorf("contract has no method %q", contract.Method)
+		}
+		contract.methodArgs, err = newContractArgs(method.Inputs, contractConfig.MethodArgs, random)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s arguments: %v", contract.Method, err)
+		}
+	}
+
+	return contract, nil
+}
+
+// newArgContext returns the argument generator context of the next
+// transaction sent by the given node and sender.
+func (c *Contract) newArgContext(node string, opts *txOpts) *argContext {
+	ctx := &argContext{
+		node:   node,
+		sender: opts.From,
+		index:  atomic.AddUint64(&c.txIndex, 1) - 1,
+	}
+	if opts.Nonce != nil {
+		ctx.nonce = opts.Nonce.Uint64()
+	}
+	return ctx
+}
+
+// SignDeploy returns a signed deploy transaction of the contract without
+// sending it.
+func (c *Contract) SignDeploy(opts *txOpts, node string) (*types.Transaction, error) {
+	if len(c.Bytecode) == 0 {
+		return nil, fmt.Errorf("%s contract has no bytecode", c.Name)
+	}
+	args, err := c.constructorArgs.generate(c.newArgContext(node, opts))
+	if err != nil {
+		return nil, err
+	}
+	input, err := c.ABI.Pack("", args...)
+	if err != nil {
+		return nil, err
+	}
+	data := append(common.CopyBytes(c.Bytecode), input...)
+	return opts.signTx(nil, opts.Value, opts.GasLimit, data)
+}
+
+// SignCall returns a signed transaction calling the contract method on the
+// given address without sending it.
+func (c *Contract) SignCall(opts *txOpts, address common.Address, node string) (*types.Transaction, error) {
+	if c.Method == "" {
+		return nil, fmt.Errorf("%s contract has no method to call", c.Name)
+	}
+	args, err := c.methodArgs.generate(c.newArgContext(node, opts))
+	if err != nil {
+		return nil, err
+	}
+	input, err := c.ABI.Pack(c.Method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return opts.signTx(&address, opts.Value, opts.GasLimit, input)
+}
@@ -3,223 +3,513 @@ package store
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
-	"math/big"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/Workiva/go-datastructures/queue"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/tubuarge/GoHammer/config"
 	"github.com/tubuarge/GoHammer/logger"
+	hammerrpc "github.com/tubuarge/GoHammer/rpc"
 	"github.com/tubuarge/GoHammer/util"
 )
 
-var deployQueue *queue.Queue
-
 type DeployClient struct {
 	Logger *logger.LogClient
+
+	// RPCClient dials the nodes.
+	RPCClient *hammerrpc.RPCClient
+
+	// GracePeriod is how long the receipts of the sent transactions are
+	// waited for after the test is interrupted.
+	GracePeriod time.Duration
+
+	// receiptsAbort is closed when the grace period is over.
+	receiptsAbort chan struct{}
+
+	nonceMu       sync.Mutex
+	nonceManagers map[common.Address]*NonceManager
+
+	contractMu sync.Mutex
+	contracts  map[*config.TestProfile]*Contract
+
+	// stats are the transaction counts of the test profiles for their stop
+	// conditions.
+	statsMu sync.Mutex
+	stats   map[*config.TestProfile]*profileStats
+
+	// senderKeys are the decrypted sender accounts by their credential
+	// config.
+	senderKeysMu sync.Mutex
+	senderKeys   map[string][]*ecdsa.PrivateKey
+
+	// signerConns are the connections to the external signers by their
+	// URL.
+	signerConnsMu sync.Mutex
+	signerConns   map[string]*signerConn
 }
 
 func NewDeployClient(logClient *logger.LogClient) *DeployClient {
-	return &DeployClient{Logger: logClient}
+	return &DeployClient{
+		Logger:        logClient,
+		RPCClient:     hammerrpc.NewRPCClient(),
+		GracePeriod:   DefaultGracePeriod,
+		nonceManagers: make(map[common.Address]*NonceManager),
+		contracts:     make(map[*config.TestProfile]*Contract),
+		stats:         make(map[*config.TestProfile]*profileStats),
+		senderKeys:    make(map[string][]*ecdsa.PrivateKey),
+		signerConns:   make(map[string]*signerConn),
+	}
 }
 
-func deployContract(conn *ethclient.Client, nodeCipher string) {
-	privateKey, err := crypto.HexToECDSA(nodeCipher)
-	if err != nil {
-		log.Fatal(err)
+// deployContract deploys the contract workload of the node.
+func deployContract(ctx context.Context, node *nodeConn) error {
+	_, err := node.signAndSend(ctx, node.nextSender(), func(opts *txOpts) (*types.Transaction, error) {
+		return node.contract.SignDeploy(opts, node.name)
+	})
+	return err
+}
+
+// getContractInstance returns the address of an instance of the contract
+// workload deployed on the given node. If the contract has an address, it is
+// used instead of deploying a new one.
+func (d *DeployClient) getContractInstance(ctx context.Context, node *nodeConn) (common.Address, error) {
+	if node.contract.Address != nil {
+		return *node.contract.Address, nil
 	}
 
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		log.Fatal("error casting public key to ECDSA")
+	from := node.nextSender()
+	tx, err := node.signAndSend(ctx, from, func(opts *txOpts) (*types.Transaction, error) {
+		return node.contract.SignDeploy(opts, node.name)
+	})
+	if err != nil {
+		return common.Address{}, err
 	}
+	return crypto.CreateAddress(from.address, tx.Nonce()), nil
+}
 
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
+// callContractMethod calls the method of the deployed contract workload.
+func (d *DeployClient) callContractMethod(ctx context.Context, contractAddress common.Address, node *nodeConn) error {
+	_, err := node.signAndSend(ctx, node.nextSender(), func(opts *txOpts) (*types.Transaction, error) {
+		return node.contract.SignCall(opts, contractAddress, node.name)
+	})
+	return err
+}
 
-	nonce, err := conn.PendingNonceAt(context.Background(), fromAddress)
-	if err != nil {
-		log.Fatal(err)
+// sendTransfer sends a value transfer from the next sender of the node.
+func (d *DeployClient) sendTransfer(ctx context.Context, node *nodeConn) error {
+	_, err := node.signAndSend(ctx, node.nextSender(), func(opts *txOpts) (*types.Transaction, error) {
+		return node.transfer.Sign(opts, node.transfer.recipient(node.senders))
+	})
+	return err
+}
+
+// DeployTestProfiles runs the given test profiles, one after the other or
+// all at the same time if concurrent is true. A failed test profile stops the
+// run if its failure policy is abort, otherwise the run continues with the
+// other test profiles. If ctx is done, the run is interrupted: no more
+// transactions are sent, the receipts are waited for until the grace period
+// is over and ErrInterrupted is returned. The test results are complete even
+// if the run fails or it is interrupted, so partial results can always be
+// written.
+func (d *DeployClient) DeployTestProfiles(ctx context.Context, testProfiles []config.TestProfile, concurrent bool) error {
+	testStartTimestamp := time.Now()
+
+	d.Logger.TestResult = &logger.TestResults{
+		TestStartTimestamp: testStartTimestamp,
+		TotalTxCount:       0,
 	}
-	gasPrice, err := conn.SuggestGasPrice(context.Background())
-	if err != nil {
-		log.Fatal(err)
+	defer func() {
+		testEndTimestamp := time.Now()
+		elapsedTime := time.Since(testStartTimestamp)
+
+		d.Logger.TestResult.TestStartTimestamp = testStartTimestamp
+		d.Logger.TestResult.TestEndTimestamp = testEndTimestamp
+		d.Logger.TestResult.OverallExecutionTime = elapsedTime
+	}()
+
+	finished := make(chan struct{})
+	defer close(finished)
+	d.receiptsAbort = make(chan struct{})
+	go d.startGracePeriod(ctx, finished)
+
+	failurePolicies := make(map[*config.TestProfile]string)
+	for i := range testProfiles {
+		policy, err := parseFailurePolicy(testProfiles[i].OnFailure)
+		if err != nil {
+			return fmt.Errorf("Error while parsing failure policy of [%s] test profile: %v", testProfiles[i].Name, err)
+		}
+		failurePolicies[&testProfiles[i]] = policy
 	}
 
-	auth := bind.NewKeyedTransactor(privateKey)
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)     // in wei
-	auth.GasLimit = uint64(300000) // in units
-	auth.GasPrice = gasPrice
+	run := newStopper(ctx)
+	defer run.cancel()
 
-	input := "1.0"
-	//address, tx, instance, err := DeployStore(auth, conn, input)
-	_, _, instance, err := DeployStore(auth, conn, input)
-	if err != nil {
-		log.Fatal(err)
+	var failedMu sync.Mutex
+	var failed []string
+	runProfile := func(profile *config.TestProfile) {
+		if run.ctx.Err() != nil {
+			return
+		}
+
+		var err error
+		if profile.RoundRobin == true {
+			err = d.TestProfileRR(run.ctx, profile)
+		} else {
+			err = d.TestProfile(run.ctx, profile)
+		}
+		if err == nil {
+			return
+		}
+
+		err = fmt.Errorf("[%s] test profile failed: %v", profile.Name, err)
+		if failurePolicies[profile] == FailurePolicyAbort {
+			log.Errorf("%v, aborting the run.", err)
+			run.stop(err)
+			return
+		}
+		log.Errorf("%v, continuing with the other test profiles.", err)
+		failedMu.Lock()
+		failed = append(failed, profile.Name)
+		failedMu.Unlock()
 	}
 
-	/*
-		log.Info("Address: ", address.Hex())
-		log.Info("Tx Hash: ", tx.Hash().Hex())
-	*/
+	if concurrent {
+		log.Infof("Running %d test profiles concurrently.", len(testProfiles))
+		var wg sync.WaitGroup
+		for i := range testProfiles {
+			wg.Add(1)
+			go func(profile *config.TestProfile) {
+				defer wg.Done()
+				runProfile(profile)
+			}(&testProfiles[i])
+		}
+		wg.Wait()
+	} else {
+		for i := range testProfiles {
+			runProfile(&testProfiles[i])
+		}
+	}
 
-	_ = instance
+	if ctx.Err() != nil {
+		d.Logger.TestResult.Interrupted = true
+		return ErrInterrupted
+	}
+	if err := run.Err(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("test profiles failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
 }
 
-// getStoreInstance returns a Store Instance deployed on the given client.
-func (d *DeployClient) getStoreInstance(conn *ethclient.Client, nodeCipher string) (*Store, error) {
-	privateKey, err := crypto.HexToECDSA(nodeCipher)
-	if err != nil {
-		log.Fatal(err)
+// startGracePeriod starts the grace period when ctx is done and closes
+// receiptsAbort when it is over, unless the run is finished before.
+func (d *DeployClient) startGracePeriod(ctx context.Context, finished <-chan struct{}) {
+	select {
+	case <-ctx.Done():
+	case <-finished:
+		return
 	}
+	log.Warnf("Test is interrupted, waiting for the pending transaction receipts up to %s...", d.GracePeriod)
+
+	timer := time.NewTimer(d.GracePeriod)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		close(d.receiptsAbort)
+	case <-finished:
+	}
+}
 
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("Error while casting public key to ECDSA")
+// TestProfile runs the given test profile on its nodes. A failed node is
+// skipped if the failure policy of the test profile is skipNode, otherwise
+// it stops the test profile and its error is returned.
+func (d *DeployClient) TestProfile(ctx context.Context, testProfile *config.TestProfile) error {
+	failurePolicy, err := parseFailurePolicy(testProfile.OnFailure)
+	if err != nil {
+		return err
 	}
 
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
+	log.Infof("Starting to test [%s]...", testProfile.Name)
 
-	nonce, err := conn.PendingNonceAt(context.Background(), fromAddress)
+	testStartTimestamp := time.Now()
+	d.Logger.WriteTestEntry(
+		"Started to test.",
+		testProfile.Name,
+		testStartTimestamp,
+		logger.SeperatorNewLine,
+	)
+	defer func() {
+		d.Logger.WriteTestEntry(
+			"Ended test.",
+			testProfile.Name,
+			time.Now(),
+			logger.SeperatorNone,
+		)
+
+		elapsedTime := time.Since(testStartTimestamp)
+		d.Logger.WriteTestEntry(
+			fmt.Sprintf("Elapsed test run time: %s", elapsedTime),
+			testProfile.Name,
+			time.Now(),
+			logger.SeperatorProfile,
+		)
+	}()
+
+	mix, err := profileMix(testProfile)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	gasPrice, err := conn.SuggestGasPrice(context.Background())
-	if err != nil {
-		return nil, err
+
+	var presigned map[*config.NodeConfig]*presignedNode
+	if testProfile.PreSign != nil {
+		presignedNodes, err := d.presignTestProfile(ctx, testProfile)
+		if err != nil {
+			return err
+		}
+		presigned = make(map[*config.NodeConfig]*presignedNode)
+		for i, presignedNode := range presignedNodes {
+			presigned[&testProfile.Nodes[i]] = presignedNode
+		}
+		// the nodes that are not run, e.g. after a stop condition, are
+		// closed too.
+		defer func() {
+			for _, presignedNode := range presignedNodes {
+				presignedNode.node.close()
+			}
+		}()
 	}
 
-	auth := bind.NewKeyedTransactor(privateKey)
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)     // in wei
-	auth.GasLimit = uint64(300000) // in units
-	auth.GasPrice = gasPrice
+	profile := newStopper(ctx)
+	defer profile.cancel()
 
-	input := "1.0"
-	//address, tx, instance, err := DeployStore(auth, conn, input)
-	_, _, instance, err := DeployStore(auth, conn, input)
+	stopWatching, err := d.watchStopConditions(testProfile, profile)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer stopWatching()
 
-	d.Logger.TestResult.TotalTxCount += 1
-	return instance, nil
-}
+	testNode := func(node *config.NodeConfig) error {
+		rate, err := getRate(testProfile, node)
+		if err != nil {
+			return fmt.Errorf("Error while parsing rate: %v", err)
+		}
 
-// callSetItem calls the deployed smart contracts SetItem method.
-func (d *DeployClient) callSetItem(storeInst *Store, conn *ethclient.Client, nodeCipher string) error {
-	privateKey, err := crypto.HexToECDSA(nodeCipher)
-	if err != nil {
-		log.Fatal(err)
+		if presigned != nil {
+			return d.testNodePresigned(profile.ctx, testProfile, node, rate, presigned[node])
+		}
+		if mix != nil {
+			return d.testNodeMix(profile.ctx, testProfile, node, rate, mix)
+		}
+		if testProfile.Transfer != nil {
+			return d.testNodeTransfer(profile.ctx, testProfile, node, rate)
+		}
+		if testProfile.CallContractMethod {
+			return d.testNodeCallMethod(profile.ctx, testProfile, node, rate)
+		}
+		return d.testNode(profile.ctx, testProfile, node, rate)
 	}
 
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return fmt.Errorf("Error while casting public key to ECDSA")
+	runNode := func(node *config.NodeConfig) {
+		if profile.ctx.Err() != nil {
+			return
+		}
+		log.Infof("Starting to deploy on [%s] node...", node.Name)
+		if err := testNode(node); err != nil {
+			if err := nodeFailed(failurePolicy, node.Name, err); err != nil {
+				profile.stop(err)
+			}
+		}
 	}
 
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
+	if testProfile.Concurrent {
+		var nodes []interface{}
+		for i := range testProfile.Nodes {
+			nodes = append(nodes, &testProfile.Nodes[i])
+		}
+		runWorkerPool(testProfile.Workers, nodes, func(node interface{}) {
+			runNode(node.(*config.NodeConfig))
+		})
+	} else {
+		for i := range testProfile.Nodes {
+			runNode(&testProfile.Nodes[i])
+		}
+	}
+
+	return profile.Err()
+}
 
-	nonce, err := conn.PendingNonceAt(context.Background(), fromAddress)
+// TestProfileRR runs the given test profile sending its transactions to its
+// nodes one after the other. If the failure policy of the test profile is
+// skipNode, failed nodes are left out of the rotation.
+func (d *DeployClient) TestProfileRR(ctx context.Context, testProfile *config.TestProfile) error {
+	failurePolicy, err := parseFailurePolicy(testProfile.OnFailure)
 	if err != nil {
 		return err
 	}
-	gasPrice, err := conn.SuggestGasPrice(context.Background())
+
+	// in round robin profiles only the test profile rate is used, it is
+	// shared by all nodes.
+	rate, err := getRate(testProfile, &config.NodeConfig{})
 	if err != nil {
-		return err
+		return fmt.Errorf("Error while parsing rate of [%s] test profile: %v", testProfile.Name, err)
 	}
 
-	auth := bind.NewKeyedTransactor(privateKey)
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)     // in wei
-	auth.GasLimit = uint64(300000) // in units
-	auth.GasPrice = gasPrice
-
-	_, err = storeInst.StoreTransactor.SetItem(auth, [32]byte{1}, [32]byte{2})
+	mix, err := profileMix(testProfile)
 	if err != nil {
 		return err
 	}
-	d.Logger.TestResult.TotalTxCount += 1
-	return nil
-}
 
-func (d *DeployClient) DeployTestProfiles(testProfiles []config.TestProfile) {
-	testStartTimestamp := time.Now()
+	var callMethodRRStructList []*callMethodRRStruct
+	var presignedNodes []*presignedNode
+	var nodeConns []*nodeConn
 
-	d.Logger.TestResult = &logger.TestResults{
-		TestStartTimestamp: testStartTimestamp,
-		TotalTxCount:       0,
+	if testProfile.PreSign != nil {
+		presignedNodes, err = d.presignTestProfile(ctx, testProfile)
+		if err != nil {
+			return err
+		}
+		for _, presignedNode := range presignedNodes {
+			nodeConns = append(nodeConns, presignedNode.node)
+		}
+	} else if mix != nil || testProfile.CallContractMethod && testProfile.Transfer == nil {
+		callMethodRRStructList, err = d.getCallMethodRRStructList(ctx, testProfile, failurePolicy)
+		for _, callMethodRRStruct := range callMethodRRStructList {
+			nodeConns = append(nodeConns, callMethodRRStruct.node)
+		}
+	} else {
+		for i := range testProfile.Nodes {
+			node, connErr := d.newNodeConn(testProfile, &testProfile.Nodes[i])
+			if connErr != nil {
+				err = nodeFailed(failurePolicy, testProfile.Nodes[i].Name,
+					fmt.Errorf("Error while connecting to node: %v", connErr))
+				if err != nil {
+					break
+				}
+				continue
+			}
+			nodeConns = append(nodeConns, node)
+		}
 	}
-
-	for _, profile := range testProfiles {
-		if profile.RoundRobin == true {
-			d.TestProfileRR(&profile)
-			continue
+	defer func() {
+		for _, node := range nodeConns {
+			node.close()
 		}
-		d.TestProfile(&profile)
+	}()
+	if err != nil {
+		return err
+	}
+	if len(nodeConns) == 0 {
+		return errors.New("every node is skipped")
 	}
 
-	testEndTimestamp := time.Now()
-	elapsedTime := time.Since(testStartTimestamp)
-
-	d.Logger.TestResult.TestStartTimestamp = testStartTimestamp
-	d.Logger.TestResult.TestEndTimestamp = testEndTimestamp
-	d.Logger.TestResult.OverallExecutionTime = elapsedTime
-}
+	nodeConfigs := make(map[string]*config.NodeConfig)
+	for i := range testProfile.Nodes {
+		nodeConfigs[testProfile.Nodes[i].Name] = &testProfile.Nodes[i]
+	}
+	var nodes []*config.NodeConfig
+	for _, node := range nodeConns {
+		nodes = append(nodes, nodeConfigs[node.name])
+	}
 
-func (d *DeployClient) TestProfile(testProfile *config.TestProfile) {
-	log.Infof("Starting to test [%s]...", testProfile.Name)
+	dispatch, err := newDispatcher(testProfile.Dispatch, nodes, d.Logger.TestResult)
+	if err != nil {
+		return err
+	}
 
-	testStartTimestamp := time.Now()
-	d.Logger.WriteTestEntry(
-		"Started to test.",
-		testProfile.Name,
-		testStartTimestamp,
-		logger.SeperatorNewLine,
-	)
+	profile := newStopper(ctx)
+	defer profile.cancel()
 
-	for _, node := range testProfile.Nodes {
-		log.Infof("Starting to deploy on [%s] node...", node.Name)
-		if testProfile.CallContractMethod {
-			d.testNodeCallMethod(&node)
+	stopWatching, err := d.watchStopConditions(testProfile, profile)
+	if err != nil {
+		return err
+	}
+	defer stopWatching()
+
+	// the reads use the contract instances of the calls.
+	var readTargets []readTarget
+	for i, node := range nodeConns {
+		target := readTarget{node: node}
+		if callMethodRRStructList != nil && (mix == nil || mix.has(WorkloadCall)) {
+			target.address = &callMethodRRStructList[i].contractAddress
 		}
-		d.testNode(&node)
+		readTargets = append(readTargets, target)
 	}
+	stopReads, err := d.startReads(profile.ctx, testProfile, readTargets...)
+	if err != nil {
+		return err
+	}
+	defer stopReads()
 
-	d.Logger.WriteTestEntry(
-		"Ended test.",
-		testProfile.Name,
-		time.Now(),
-		logger.SeperatorNone,
-	)
+	// failed nodes are disabled in the dispatcher.
+	sendRR := func(int) {
+		index := dispatch.dispatch()
+		if index < 0 {
+			return
+		}
+		defer dispatch.done(index)
+
+		var err error
+		switch {
+		case presignedNodes != nil:
+			err = presignedNodes[index].sendAndLog(profile.ctx)
+		case mix != nil:
+			err = d.sendMixTx(profile.ctx, mix, callMethodRRStructList[index].node, callMethodRRStructList[index].contractAddress)
+		case testProfile.Transfer != nil:
+			err = d.testNodeRRTransfer(profile.ctx, nodeConns[index])
+		case testProfile.CallContractMethod:
+			err = d.testNodeRRCallMethod(profile.ctx, callMethodRRStructList[index])
+		default:
+			err = d.testNodeRR(profile.ctx, nodeConns[index])
+		}
 
-	elapsedTime := time.Since(testStartTimestamp)
-	d.Logger.WriteTestEntry(
-		fmt.Sprintf("Elapsed test run time: %s", elapsedTime),
-		testProfile.Name,
-		time.Now(),
-		logger.SeperatorProfile,
-	)
-}
+		var abortErr *abortError
+		if !errors.As(err, &abortErr) || !dispatch.disable(index) {
+			return
+		}
+		if err := nodeFailed(failurePolicy, nodeConns[index].name, err); err != nil {
+			profile.stop(err)
+			return
+		}
+		if dispatch.enabledCount() == 0 {
+			profile.stop(errors.New("every node failed"))
+		}
+	}
 
-func (d *DeployClient) TestProfileRR(testProfile *config.TestProfile) {
-	var callMethodRRStructList []*callMethodRRStruct
+	// with stop conditions the load is repeated until the test profile is
+	// stopped.
+	repeat := testProfile.Stop != nil
 
-	if testProfile.CallContractMethod {
-		callMethodRRStructList = d.getCallMethodRRStructList(testProfile)
+	if len(testProfile.Phases) > 0 {
+		for {
+			if err := d.runPhases(profile.ctx, testProfile.Name, testProfile.Phases, sendRR); err != nil {
+				return err
+			}
+			if !repeat || profile.ctx.Err() != nil {
+				return profile.Err()
+			}
+		}
 	}
 
-	node := testProfile.Nodes[0]
-
-	for _, deployCount := range node.DeployCounts {
+	deployCounts := rrDeployCounts(nodes)
+	if repeat && len(deployCounts) == 0 && testProfile.Reads != nil {
+		// a read only test profile reads until it is stopped.
+		<-profile.ctx.Done()
+	}
+	for i := 0; i < len(deployCounts) || repeat && len(deployCounts) > 0; i++ {
+		if profile.ctx.Err() != nil {
+			break
+		}
+		deployCount := deployCounts[i%len(deployCounts)]
 		testStartTimestamp := time.Now()
 
 		d.Logger.WriteTestEntry(
@@ -229,42 +519,200 @@ func (d *DeployClient) TestProfileRR(testProfile *config.TestProfile) {
 			logger.SeperatorNone,
 		)
 
-		for j := 0; j < deployCount; j++ {
-			for i := 0; i <= len(testProfile.Nodes)-1; i++ {
-				if testProfile.CallContractMethod {
-					d.testNodeRRCallMethod(callMethodRRStructList[i])
-					continue
-				}
-				d.testNodeRR(&testProfile.Nodes[i])
+		d.sendTxs(
+			profile.ctx,
+			fmt.Sprintf("%s - %d", testProfile.Name, deployCount),
+			rate,
+			unthrottledConcurrency(testProfile),
+			deployCount,
+			sendRR,
+		)
+	}
+	return profile.Err()
+}
+
+// rrDeployCounts returns the deploy counts of a round robin test profile,
+// every count is the sum of the deploy counts of the nodes in that turn.
+func rrDeployCounts(nodes []*config.NodeConfig) []int {
+	var deployCounts []int
+	for _, node := range nodes {
+		for i, deployCount := range node.DeployCounts {
+			if i == len(deployCounts) {
+				deployCounts = append(deployCounts, 0)
 			}
+			deployCounts[i] += deployCount
 		}
 	}
+	return deployCounts
 }
 
-func (d *DeployClient) testNodeRR(nodeConfig *config.NodeConfig) {
-	conn, err := createConn(nodeConfig.URL)
-	if err != nil {
-		log.Fatalf("Error while creating ETH Client Connection: %v", err)
+func (d *DeployClient) testNodeRR(ctx context.Context, node *nodeConn) error {
+	if err := deployContract(ctx, node); err != nil {
+		return err
 	}
 
-	deployContract(conn, nodeConfig.Cipher)
-
-	log.Infof("[%s] deployed on.", nodeConfig.Name)
+	log.Infof("[%s] deployed on.", node.name)
+	return nil
 }
 
 // testNodeRRCallMethod is wrapper function that used when running Round Robin and
 // callMethod test profile.
-func (d *DeployClient) testNodeRRCallMethod(callMethodRRStruct *callMethodRRStruct) {
-	d.callSetItem(callMethodRRStruct.storeInst, callMethodRRStruct.ethClient, callMethodRRStruct.nodeCipher)
+func (d *DeployClient) testNodeRRCallMethod(ctx context.Context, callMethodRRStruct *callMethodRRStruct) error {
+	return d.callContractMethod(ctx, callMethodRRStruct.contractAddress, callMethodRRStruct.node)
+}
+
+// testNodeRRTransfer is wrapper function that used when running Round Robin and
+// transfer test profile.
+func (d *DeployClient) testNodeRRTransfer(ctx context.Context, node *nodeConn) error {
+	return d.sendTransfer(ctx, node)
 }
 
-func (d *DeployClient) testNode(nodeConfig *config.NodeConfig) {
-	conn, err := createConn(nodeConfig.URL)
+func (d *DeployClient) testNode(ctx context.Context, testProfile *config.TestProfile, nodeConfig *config.NodeConfig, rate float64) error {
+	node, err := d.newNodeConn(testProfile, nodeConfig)
 	if err != nil {
-		log.Fatalf("Error while creating ETH Client Connection: %v", err)
+		return fmt.Errorf("Error while connecting to node: %v", err)
 	}
+	defer node.close()
+
+	stopReads, err := d.startReads(ctx, testProfile, readTarget{node: node})
+	if err != nil {
+		return err
+	}
+	defer stopReads()
+
+	return d.runNodeLoad(ctx, testProfile, nodeConfig, rate, func(ctx context.Context) error {
+		return deployContract(ctx, node)
+	})
+}
+
+func (d *DeployClient) testNodeCallMethod(ctx context.Context, testProfile *config.TestProfile, nodeConfig *config.NodeConfig, rate float64) error {
+	node, err := d.newNodeConn(testProfile, nodeConfig)
+	if err != nil {
+		return fmt.Errorf("Error while connecting to node: %v", err)
+	}
+	defer node.close()
+
+	contractAddress, err := d.getContractInstance(ctx, node)
+	if err != nil {
+		return fmt.Errorf("Error while creating %s Instance: %v", node.contract.Name, err)
+	}
+
+	stopReads, err := d.startReads(ctx, testProfile, readTarget{node: node, address: &contractAddress})
+	if err != nil {
+		return err
+	}
+	defer stopReads()
+
+	return d.runNodeLoad(ctx, testProfile, nodeConfig, rate, func(ctx context.Context) error {
+		log.Infof("Calling %s method", node.contract.Method)
+		return d.callContractMethod(ctx, contractAddress, node)
+	})
+}
+
+func (d *DeployClient) testNodeTransfer(ctx context.Context, testProfile *config.TestProfile, nodeConfig *config.NodeConfig, rate float64) error {
+	node, err := d.newNodeConn(testProfile, nodeConfig)
+	if err != nil {
+		return fmt.Errorf("Error while connecting to node: %v", err)
+	}
+	defer node.close()
+
+	stopReads, err := d.startReads(ctx, testProfile, readTarget{node: node})
+	if err != nil {
+		return err
+	}
+	defer stopReads()
+
+	return d.runNodeLoad(ctx, testProfile, nodeConfig, rate, func(ctx context.Context) error {
+		return d.sendTransfer(ctx, node)
+	})
+}
+
+// testNodeMix sends the transactions of the mixed workload of the given
+// node.
+func (d *DeployClient) testNodeMix(ctx context.Context, testProfile *config.TestProfile, nodeConfig *config.NodeConfig, rate float64, mix *txMix) error {
+	node, err := d.newNodeConn(testProfile, nodeConfig)
+	if err != nil {
+		return fmt.Errorf("Error while connecting to node: %v", err)
+	}
+	defer node.close()
+
+	var contractAddress common.Address
+	target := readTarget{node: node}
+	if mix.has(WorkloadCall) {
+		contractAddress, err = d.getContractInstance(ctx, node)
+		if err != nil {
+			return fmt.Errorf("Error while creating %s Instance: %v", node.contract.Name, err)
+		}
+		target.address = &contractAddress
+	}
+
+	stopReads, err := d.startReads(ctx, testProfile, target)
+	if err != nil {
+		return err
+	}
+	defer stopReads()
+
+	return d.runNodeLoad(ctx, testProfile, nodeConfig, rate, func(ctx context.Context) error {
+		return d.sendMixTx(ctx, mix, node, contractAddress)
+	})
+}
+
+// testNodePresigned sends the pre-signed transactions of the given node.
+func (d *DeployClient) testNodePresigned(ctx context.Context, testProfile *config.TestProfile, nodeConfig *config.NodeConfig, rate float64, presigned *presignedNode) error {
+	defer presigned.node.close()
+
+	stopReads, err := d.startReads(ctx, testProfile, readTarget{node: presigned.node})
+	if err != nil {
+		return err
+	}
+	defer stopReads()
+
+	return d.runNodeLoad(ctx, testProfile, nodeConfig, rate, presigned.sendAndLog)
+}
+
+// runNodeLoad sends the transactions of the given node with the send
+// function, according to the test profile phases if there are any,
+// otherwise according to the node deploy counts. If the test profile has
+// stop conditions, the load is repeated until the test profile is stopped,
+// a test profile with reads and no write load waits for that.
+// The node fails and the load stops on the first abortError of send, other
+// send errors are handled by the error policy of the node.
+func (d *DeployClient) runNodeLoad(ctx context.Context, testProfile *config.TestProfile, nodeConfig *config.NodeConfig,
+	rate float64, send func(ctx context.Context) error) error {
+	node := newStopper(ctx)
+	defer node.cancel()
+
+	sendNode := func(int) {
+		var abortErr *abortError
+		if err := send(node.ctx); errors.As(err, &abortErr) {
+			node.stop(err)
+		}
+	}
+
+	repeat := testProfile.Stop != nil
+
+	if len(testProfile.Phases) > 0 {
+		for {
+			if err := d.runPhases(node.ctx, nodeConfig.Name, testProfile.Phases, sendNode); err != nil {
+				return err
+			}
+			if !repeat || node.ctx.Err() != nil {
+				return node.Err()
+			}
+		}
+	}
+
+	deployCounts := nodeConfig.DeployCounts
+	if repeat && len(deployCounts) == 0 && testProfile.Reads != nil {
+		// a read only test profile reads until it is stopped.
+		<-node.ctx.Done()
+	}
+	for i := 0; i < len(deployCounts) || repeat && len(deployCounts) > 0; i++ {
+		if node.ctx.Err() != nil {
+			break
+		}
+		deployCount := deployCounts[i%len(deployCounts)]
 
-	for _, deployCount := range nodeConfig.DeployCounts {
 		testStartTimestamp := time.Now()
 		d.Logger.WriteTestEntry(
 			"Started to test.",
@@ -273,10 +721,14 @@ func (d *DeployClient) testNode(nodeConfig *config.NodeConfig) {
 			logger.SeperatorNone,
 		)
 
-		for i := 0; i < deployCount; i++ {
-			deployContract(conn, nodeConfig.Cipher)
-			d.Logger.TestResult.TotalTxCount++
-		}
+		d.sendTxs(
+			node.ctx,
+			fmt.Sprintf("%s - %d", nodeConfig.Name, deployCount),
+			rate,
+			unthrottledConcurrency(testProfile),
+			deployCount,
+			sendNode,
+		)
 
 		log.Infof("Deployed %d transaction on the given node.", deployCount)
 		d.Logger.WriteTestEntry(
@@ -287,7 +739,6 @@ func (d *DeployClient) testNode(nodeConfig *config.NodeConfig) {
 		)
 
 		elapsedTime := time.Since(testStartTimestamp)
-		d.Logger.TestResult.OverallExecutionTime += elapsedTime
 		d.Logger.WriteTestEntry(
 			fmt.Sprintf("Elapsed test run time: %s", elapsedTime),
 			fmt.Sprintf("%s - %d", nodeConfig.Name, deployCount),
@@ -299,107 +750,168 @@ func (d *DeployClient) testNode(nodeConfig *config.NodeConfig) {
 		if err != nil {
 			log.Errorf("Error while parsing deploy intervar: %v", err)
 		}
-		time.Sleep(duration)
+		sleepContext(node.ctx, duration)
 	}
+	return node.Err()
 }
 
-func (d *DeployClient) testNodeCallMethod(nodeConfig *config.NodeConfig) {
-	conn, err := createConn(nodeConfig.URL)
-	if err != nil {
-		log.Fatalf("Error while creating ETH Client Connection: %v", err)
-	}
-
-	storeInst, err := d.getStoreInstance(conn, nodeConfig.Cipher)
-	if err != nil {
-		log.Fatalf("Error while creating Store Instance: %v", err)
+// runPhases runs the given load shape phases in order with the send
+// function and writes a test entry at the boundaries of every phase. The
+// phases stop when ctx is done.
+func (d *DeployClient) runPhases(ctx context.Context, name string, phases []config.Phase, send func(i int)) error {
+	var shapes []*loadShape
+	for i := range phases {
+		shape, err := newLoadShape(&phases[i], i)
+		if err != nil {
+			return fmt.Errorf("Error while parsing phases of [%s]: %v", name, err)
+		}
+		shapes = append(shapes, shape)
 	}
 
-	for _, deployCount := range nodeConfig.DeployCounts {
-		testStartTimestamp := time.Now()
-		d.Logger.WriteTestEntry(
-			"Started to test.",
-			fmt.Sprintf("%s - %d", nodeConfig.Name, deployCount),
-			testStartTimestamp,
-			logger.SeperatorNone,
-		)
-
-		for i := 0; i < deployCount; i++ {
-			log.Info("Calling SetItem method")
-			d.callSetItem(storeInst, conn, nodeConfig.Cipher)
-			//d.deployContract(conn, nodeConfig.Cipher)
+	for i, shape := range shapes {
+		if ctx.Err() != nil {
+			break
 		}
 
-		log.Infof("Deployed %d transaction on the given node.", deployCount)
+		entryTitle := fmt.Sprintf("%s - %s", name, shape.name)
+		log.Infof("[%s] Starting %s phase...", entryTitle, phases[i].Type)
 		d.Logger.WriteTestEntry(
-			"Ended test.",
-			fmt.Sprintf("%s - %d", nodeConfig.Name, deployCount),
+			fmt.Sprintf("Started %s phase: %s for %s.", phases[i].Type, shape.description, shape.duration),
+			entryTitle,
 			time.Now(),
 			logger.SeperatorNone,
 		)
 
-		elapsedTime := time.Since(testStartTimestamp)
-		d.Logger.TestResult.OverallExecutionTime += elapsedTime
+		// the target is taken from the rate function, not from the issued
+		// transactions, so a phase that falls behind shows a shortfall.
+		scheduler := NewShapedScheduler(shape.rateFunc)
+		expectedCount := scheduler.ExpectedCount(shape.duration)
+		targetRate := expectedCount / shape.duration.Seconds()
+		txCount, achievedRate := scheduler.RunFor(ctx, shape.duration, send)
+		log.Infof("[%s] transaction count: %d of %.0f, target rate: %.2f tx/s, achieved rate: %.2f tx/s",
+			entryTitle, txCount, expectedCount, targetRate, achievedRate)
+
 		d.Logger.WriteTestEntry(
-			fmt.Sprintf("Elapsed test run time: %s", elapsedTime),
-			fmt.Sprintf("%s - %d", nodeConfig.Name, deployCount),
+			fmt.Sprintf("Ended %s phase. Transaction count: %d of %.0f, target rate: %.2f tx/s, achieved rate: %.2f tx/s.",
+				phases[i].Type, txCount, expectedCount, targetRate, achievedRate),
+			entryTitle,
 			time.Now(),
 			logger.SeperatorNewLine,
 		)
+		d.Logger.TestResult.AddRateResult(logger.RateResult{
+			Name:         entryTitle,
+			TxCount:      txCount,
+			TargetRate:   targetRate,
+			AchievedRate: achievedRate,
+		})
+	}
+	return nil
+}
 
-		duration, err := util.ParseDuration(nodeConfig.DeployInterval)
-		if err != nil {
-			log.Errorf("Error while parsing deploy intervar: %v", err)
+// sendTxs calls send deployCount times, or until ctx is done. If rate is
+// greater than zero the calls are issued by a Scheduler at the given rate
+// and the achieved rate is added to the test results, otherwise they are run
+// back-to-back, concurrency calls at a time.
+func (d *DeployClient) sendTxs(ctx context.Context, name string, rate float64, concurrency int, deployCount int, send func(i int)) {
+	if rate <= 0 {
+		for i := 0; i < deployCount && ctx.Err() == nil; i += concurrency {
+			var wg sync.WaitGroup
+			for j := i; j < i+concurrency && j < deployCount; j++ {
+				wg.Add(1)
+				go func(j int) {
+					defer wg.Done()
+					send(j)
+				}(j)
+			}
+			wg.Wait()
 		}
-		time.Sleep(duration)
+		return
 	}
+
+	txCount, achievedRate := NewScheduler(rate).Run(ctx, deployCount, send)
+	log.Infof("[%s] target rate: %.2f tx/s, achieved rate: %.2f tx/s", name, rate, achievedRate)
+
+	d.Logger.TestResult.AddRateResult(logger.RateResult{
+		Name:         name,
+		TxCount:      txCount,
+		TargetRate:   rate,
+		AchievedRate: achievedRate,
+	})
 }
 
-// callMethodRRStruct contains information for calling callSetItem
+// getRate returns the target rate of the given node in transactions per
+// second. If the node has no rate, the test profile rate is used. Returns 0
+// if neither of them has a rate.
+func getRate(testProfile *config.TestProfile, nodeConfig *config.NodeConfig) (float64, error) {
+	rateStr := nodeConfig.Rate
+	if rateStr == "" {
+		rateStr = testProfile.Rate
+	}
+	if rateStr == "" {
+		return 0, nil
+	}
+	return util.ParseRate(rateStr)
+}
+
+// callMethodRRStruct contains information for calling callContractMethod
 type callMethodRRStruct struct {
-	ethClient  *ethclient.Client
-	storeInst  *Store
-	nodeCipher string
+	node            *nodeConn
+	contractAddress common.Address
 }
 
 // getCallMethodRRStructList returns a list of callMethodRRStruct struct that contains
-// required values to call callSetItem function.
-// if there is a problem occured while creating ethclient or store instance,
-// terminates the program.
-func (d *DeployClient) getCallMethodRRStructList(testProfile *config.TestProfile) []*callMethodRRStruct {
+// required values to call callContractMethod function.
+// If a node can't be connected or its contract instance can't be created, it
+// is skipped or an error is returned according to the failure policy.
+func (d *DeployClient) getCallMethodRRStructList(ctx context.Context, testProfile *config.TestProfile, failurePolicy string) ([]*callMethodRRStruct, error) {
 	var callMethodRRStructList []*callMethodRRStruct
 
 	// create connections and store instance for every node in the test profile and
 	// create a callMethoRRStruct, then add this struct to the slice.
 	nodes := testProfile.Nodes
-	for _, node := range nodes {
-		conn, err := createConn(node.URL)
+	for i := range nodes {
+		node, err := d.newNodeConn(testProfile, &nodes[i])
 		if err != nil {
-			log.Fatalf("Error while creating ETH Client Connection: %v", err)
-			return nil
+			err = nodeFailed(failurePolicy, nodes[i].Name, fmt.Errorf("Error while connecting to node: %v", err))
+			if err != nil {
+				return callMethodRRStructList, err
+			}
+			continue
+		}
+
+		// a mixed workload needs a contract instance only for its calls.
+		if testProfile.Mix != nil && testProfile.Mix.Call == 0 {
+			callMethodRRStructList = append(callMethodRRStructList, &callMethodRRStruct{node: node})
+			continue
 		}
 
-		storeInst, err := d.getStoreInstance(conn, node.Cipher)
+		contractAddress, err := d.getContractInstance(ctx, node)
 		if err != nil {
-			log.Fatalf("Error while creating Store Instance: %v", err)
-			return nil
+			node.close()
+			err = nodeFailed(failurePolicy, nodes[i].Name, fmt.Errorf("Error while creating %s Instance: %v", node.contract.Name, err))
+			if err != nil {
+				return callMethodRRStructList, err
+			}
+			continue
 		}
 
 		structInst := &callMethodRRStruct{
-			ethClient:  conn,
-			storeInst:  storeInst,
-			nodeCipher: node.Cipher,
+			node:            node,
+			contractAddress: contractAddress,
 		}
 
 		callMethodRRStructList = append(callMethodRRStructList, structInst)
 	}
 
-	return callMethodRRStructList
+	return callMethodRRStructList, nil
 }
 
-func createConn(nodeUrl string) (*ethclient.Client, error) {
-	conn, err := ethclient.Dial(nodeUrl)
-	if err != nil {
-		return nil, err
-	}
-	return conn, nil
+// createConn dials the given node with the rpc client of the deploy
+// client, so the transport is chosen by its URL (HTTP, WebSocket or IPC) in
+// one place for the health check and the load. Dialing is bounded by the
+// timeout of the rpc client.
+func (d *DeployClient) createConn(nodeUrl string) (*rpc.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.RPCClient.Timeout)
+	defer cancel()
+	return d.RPCClient.Dial(ctx, nodeUrl)
 }
@@ -0,0 +1,190 @@
+package store
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/logger"
+)
+
+// Dispatch strategies of round robin test profiles.
+const (
+	DispatchRoundRobin       = "roundRobin"
+	DispatchWeighted         = "weighted"
+	DispatchLeastOutstanding = "leastOutstanding"
+	DispatchRandom           = "random"
+)
+
+// dispatcher chooses the node of every transaction of a round robin test
+// profile. Failed nodes are disabled and never chosen again.
+type dispatcher struct {
+	strategy string
+	names    []string
+	results  *logger.TestResults
+
+	mu sync.Mutex
+	// next is the next node of the round robin strategy.
+	next     int
+	weights  []int
+	disabled []bool
+	enabled  int
+	// current is the current weight of every node in the smooth weighted
+	// round robin of the weighted strategy.
+	current []int
+	// outstanding is the number of transactions in flight of every node.
+	outstanding []int
+	rand        *rand.Rand
+}
+
+func newDispatcher(strategy string, nodes []*config.NodeConfig, results *logger.TestResults) (*dispatcher, error) {
+	if strategy == "" {
+		strategy = DispatchRoundRobin
+	}
+	switch strategy {
+	case DispatchRoundRobin, DispatchWeighted, DispatchLeastOutstanding, DispatchRandom:
+	default:
+		return nil, fmt.Errorf("unknown dispatch strategy: %q", strategy)
+	}
+
+	d := &dispatcher{
+		strategy:    strategy,
+		results:     results,
+		disabled:    make([]bool, len(nodes)),
+		enabled:     len(nodes),
+		current:     make([]int, len(nodes)),
+		outstanding: make([]int, len(nodes)),
+		rand:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, node := range nodes {
+		if node.Weight < 0 {
+			return nil, fmt.Errorf("weight of [%s] node can't be negative: %d", node.Name, node.Weight)
+		}
+		weight := node.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		d.names = append(d.names, node.Name)
+		d.weights = append(d.weights, weight)
+	}
+	return d, nil
+}
+
+// dispatch returns the node of the next transaction, or -1 if every node is
+// disabled. done has to be called with the node when the transaction is
+// sent.
+func (d *dispatcher) dispatch() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.enabled == 0 {
+		return -1
+	}
+
+	var index int
+	switch d.strategy {
+	case DispatchWeighted:
+		index = d.nextWeighted()
+	case DispatchLeastOutstanding:
+		index = d.nextLeastOutstanding()
+	case DispatchRandom:
+		index = d.nextRandom()
+	default:
+		index = d.nextRoundRobin()
+	}
+
+	d.outstanding[index]++
+	if d.results != nil {
+		d.results.AddDispatchedTx(d.names[index])
+	}
+	return index
+}
+
+// done marks a transaction of the given node as sent.
+func (d *dispatcher) done(index int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.outstanding[index]--
+}
+
+// disable removes the given node from the dispatch, it returns false if the
+// node is already disabled.
+func (d *dispatcher) disable(index int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.disabled[index] {
+		return false
+	}
+	d.disabled[index] = true
+	d.enabled--
+	return true
+}
+
+// enabledCount returns the number of nodes that are not disabled.
+func (d *dispatcher) enabledCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.enabled
+}
+
+func (d *dispatcher) nextRoundRobin() int {
+	for {
+		index := d.next
+		d.next = (d.next + 1) % len(d.names)
+		if !d.disabled[index] {
+			return index
+		}
+	}
+}
+
+// nextWeighted is the smooth weighted round robin of nginx, so the
+// transactions of a node are spread over the round instead of sent in a
+// row.
+func (d *dispatcher) nextWeighted() int {
+	best, total := -1, 0
+	for i, weight := range d.weights {
+		if d.disabled[i] {
+			continue
+		}
+		d.current[i] += weight
+		total += weight
+		if best < 0 || d.current[i] > d.current[best] {
+			best = i
+		}
+	}
+	d.current[best] -= total
+	return best
+}
+
+func (d *dispatcher) nextLeastOutstanding() int {
+	// ties are broken in round robin order, so idle nodes are used evenly.
+	best := -1
+	for n := 0; n < len(d.names); n++ {
+		i := (d.next + n) % len(d.names)
+		if d.disabled[i] {
+			continue
+		}
+		if best < 0 || d.outstanding[i] < d.outstanding[best] {
+			best = i
+		}
+	}
+	d.next = (best + 1) % len(d.names)
+	return best
+}
+
+func (d *dispatcher) nextRandom() int {
+	n := d.rand.Intn(d.enabled)
+	for i := range d.names {
+		if d.disabled[i] {
+			continue
+		}
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return -1
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/logger"
+)
+
+func testDispatchNodes(weights ...int) []*config.NodeConfig {
+	var nodes []*config.NodeConfig
+	for i, weight := range weights {
+		nodes = append(nodes, &config.NodeConfig{Name: string(rune('a' + i)), Weight: weight})
+	}
+	return nodes
+}
+
+// dispatchN dispatches n transactions, they are all sent at once.
+func dispatchN(d *dispatcher, n int) []int {
+	var indexes []int
+	for i := 0; i < n; i++ {
+		index := d.dispatch()
+		indexes = append(indexes, index)
+		d.done(index)
+	}
+	return indexes
+}
+
+func TestDispatcherStrategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		weights  []int
+		want     []int
+	}{
+		{"", []int{0, 0, 0}, []int{0, 1, 2, 0, 1, 2}},
+		{DispatchRoundRobin, []int{5, 1, 1}, []int{0, 1, 2, 0, 1, 2}},
+		// the smooth weighted round robin spreads the transactions of a
+		// node over the round.
+		{DispatchWeighted, []int{5, 1, 1}, []int{0, 0, 1, 0, 2, 0, 0}},
+		{DispatchWeighted, []int{2, 0}, []int{0, 1, 0, 0, 1, 0}},
+		{DispatchLeastOutstanding, []int{1, 1, 1}, []int{0, 1, 2, 0, 1, 2}},
+	}
+	for _, test := range tests {
+		d, err := newDispatcher(test.strategy, testDispatchNodes(test.weights...), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := dispatchN(d, len(test.want)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %v: dispatched to %v, want %v", test.strategy, test.weights, got, test.want)
+		}
+	}
+}
+
+func TestDispatcherLeastOutstanding(t *testing.T) {
+	d, err := newDispatcher(DispatchLeastOutstanding, testDispatchNodes(1, 1, 1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// node 0 and 2 are busy, node 1 is done.
+	for _, want := range []int{0, 1, 2} {
+		if index := d.dispatch(); index != want {
+			t.Fatalf("dispatched to %d, want %d", index, want)
+		}
+	}
+	d.done(1)
+	if index := d.dispatch(); index != 1 {
+		t.Errorf("dispatched to %d, want the node with the fewest transactions in flight", index)
+	}
+}
+
+func TestDispatcherDisable(t *testing.T) {
+	for _, strategy := range []string{DispatchRoundRobin, DispatchWeighted, DispatchLeastOutstanding, DispatchRandom} {
+		d, err := newDispatcher(strategy, testDispatchNodes(1, 1, 1), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !d.disable(1) || d.disable(1) {
+			t.Errorf("%s: disable didn't report the node state", strategy)
+		}
+		for _, index := range dispatchN(d, 20) {
+			if index == 1 {
+				t.Errorf("%s: dispatched to a disabled node", strategy)
+				break
+			}
+		}
+
+		d.disable(0)
+		d.disable(2)
+		if d.enabledCount() != 0 || d.dispatch() != -1 {
+			t.Errorf("%s: dispatched with every node disabled", strategy)
+		}
+	}
+}
+
+func TestDispatcherResults(t *testing.T) {
+	results := &logger.TestResults{}
+	d, err := newDispatcher(DispatchWeighted, testDispatchNodes(3, 1), results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dispatchN(d, 8)
+
+	want := map[string]int{"a": 6, "b": 2}
+	if !reflect.DeepEqual(results.DispatchedTxCounts, want) {
+		t.Errorf("dispatched transaction counts = %v, want %v", results.DispatchedTxCounts, want)
+	}
+}
+
+func TestNewDispatcherInvalid(t *testing.T) {
+	if _, err := newDispatcher("fastest", testDispatchNodes(1), nil); err == nil {
+		t.Error("newDispatcher didn't fail on an unknown strategy")
+	}
+	if _, err := newDispatcher(DispatchWeighted, testDispatchNodes(1, -1), nil); err == nil {
+		t.Error("newDispatcher didn't fail on a negative weight")
+	}
+}
+
+func TestRRDeployCounts(t *testing.T) {
+	nodes := []*config.NodeConfig{
+		{DeployCounts: []int{10, 20}},
+		{DeployCounts: []int{10, 20, 30}},
+	}
+	if got, want := rrDeployCounts(nodes), []int{20, 40, 30}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rrDeployCounts = %v, want %v", got, want)
+	}
+}
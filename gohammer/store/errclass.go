@@ -0,0 +1,186 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/util"
+)
+
+// Transaction error classes.
+const (
+	ErrClassNonceTooLow            = "nonceTooLow"
+	ErrClassReplacementUnderpriced = "replacementUnderpriced"
+	ErrClassTxPoolFull             = "txpoolFull"
+	ErrClassInsufficientFunds      = "insufficientFunds"
+	ErrClassConnectionReset        = "connectionReset"
+	ErrClassTimeout                = "timeout"
+	ErrClassOther                  = "other"
+)
+
+// Error policies.
+const (
+	// ErrPolicyRetry resends the transaction after a backoff, if every
+	// retry fails the nonce of the sender is resynced.
+	ErrPolicyRetry = "retry"
+	// ErrPolicyResync drops the transaction and resyncs the nonce of the
+	// sender.
+	ErrPolicyResync = "resync"
+	// ErrPolicySkip drops the transaction.
+	ErrPolicySkip = "skip"
+	// ErrPolicyAbort fails the node, what happens next is decided by the
+	// failure policy of the test profile.
+	ErrPolicyAbort = "abort"
+)
+
+const (
+	DefaultErrorRetries = 3
+	DefaultErrorBackoff = 100 * time.Millisecond
+
+	// MaxErrorBackoff is the maximum delay before a retry, unless the
+	// configured backoff is longer.
+	MaxErrorBackoff = 30 * time.Second
+)
+
+// defaultErrorPolicies are the policies of the error classes that are not
+// configured.
+var defaultErrorPolicies = map[string]string{
+	ErrClassNonceTooLow:            ErrPolicyResync,
+	ErrClassReplacementUnderpriced: ErrPolicyResync,
+	ErrClassTxPoolFull:             ErrPolicyRetry,
+	ErrClassInsufficientFunds:      ErrPolicySkip,
+	ErrClassConnectionReset:        ErrPolicyRetry,
+	ErrClassTimeout:                ErrPolicyRetry,
+	ErrClassOther:                  ErrPolicySkip,
+}
+
+// classifyError returns the error class of a failed transaction.
+func classifyError(err error) string {
+	msg := strings.ToLower(err.Error())
+	switch {
+	// a nonce that is too high means the local nonce is out of sync too.
+	case strings.Contains(msg, "nonce too low"), strings.Contains(msg, "nonce too high"):
+		return ErrClassNonceTooLow
+	case strings.Contains(msg, "replacement transaction underpriced"):
+		return ErrClassReplacementUnderpriced
+	case strings.Contains(msg, "txpool is full"), strings.Contains(msg, "transaction pool is full"):
+		return ErrClassTxPoolFull
+	case strings.Contains(msg, "insufficient funds"):
+		return ErrClassInsufficientFunds
+	case isTimeoutError(err), strings.Contains(msg, "timeout"):
+		return ErrClassTimeout
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF),
+		strings.Contains(msg, "connection reset"), strings.Contains(msg, "connection refused"),
+		strings.Contains(msg, "broken pipe"), strings.HasSuffix(msg, "eof"):
+		return ErrClassConnectionReset
+	}
+	return ErrClassOther
+}
+
+func isTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isKnownTxError reports whether the node already has a resent transaction,
+// so an earlier attempt reached the node even though it failed.
+func isKnownTxError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction")
+}
+
+// mayHaveReachedNode reports whether a transaction that failed with the
+// given error class may still be in the pool of the node, e.g. the request
+// timed out or the connection was reset after the node read it. Its nonce
+// can't be given back then.
+func mayHaveReachedNode(class string) bool {
+	return class == ErrClassTimeout || class == ErrClassConnectionReset
+}
+
+// errorPolicy returns what to do with a failed transaction by its error
+// class.
+type errorPolicy struct {
+	policies map[string]string
+	retries  int
+	backoff  time.Duration
+}
+
+func newErrorPolicy(errorConfig *config.ErrorConfig) (*errorPolicy, error) {
+	if errorConfig == nil {
+		errorConfig = &config.ErrorConfig{}
+	}
+
+	e := &errorPolicy{
+		policies: make(map[string]string),
+		retries:  DefaultErrorRetries,
+		backoff:  DefaultErrorBackoff,
+	}
+	for class, policy := range defaultErrorPolicies {
+		e.policies[class] = policy
+	}
+	for class, policy := range errorConfig.Policies {
+		if _, ok := defaultErrorPolicies[class]; !ok {
+			return nil, fmt.Errorf("unknown error class: %q", class)
+		}
+		switch policy {
+		case ErrPolicyRetry, ErrPolicyResync, ErrPolicySkip, ErrPolicyAbort:
+		default:
+			return nil, fmt.Errorf("unknown error policy of %s: %q", class, policy)
+		}
+		e.policies[class] = policy
+	}
+
+	if errorConfig.Retries < 0 {
+		return nil, fmt.Errorf("retries can't be negative: %d", errorConfig.Retries)
+	}
+	if errorConfig.Retries > 0 {
+		e.retries = errorConfig.Retries
+	}
+	if errorConfig.Backoff != "" {
+		backoff, err := util.ParseDuration(errorConfig.Backoff)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backoff: %v", err)
+		}
+		e.backoff = backoff
+	}
+	return e, nil
+}
+
+// policy returns the policy of the given error class.
+func (e *errorPolicy) policy(class string) string {
+	return e.policies[class]
+}
+
+// retryDelay returns the delay before the given retry, starting from 0, or
+// false if the transaction shouldn't be retried anymore. The backoff is
+// doubled on every retry up to MaxErrorBackoff, or the backoff itself if it
+// is longer, so many retries can't overflow the delay.
+func (e *errorPolicy) retryDelay(class string, retry int) (time.Duration, bool) {
+	if e.policy(class) != ErrPolicyRetry || retry >= e.retries {
+		return 0, false
+	}
+
+	maxDelay := MaxErrorBackoff
+	if e.backoff > maxDelay {
+		maxDelay = e.backoff
+	}
+	delay := e.backoff
+	for i := 0; i < retry && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay, true
+}
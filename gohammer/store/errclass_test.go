@@ -0,0 +1,194 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/logger"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{errors.New("nonce too low"), ErrClassNonceTooLow},
+		{errors.New("nonce too high"), ErrClassNonceTooLow},
+		{errors.New("replacement transaction underpriced"), ErrClassReplacementUnderpriced},
+		{errors.New("txpool is full"), ErrClassTxPoolFull},
+		{errors.New("insufficient funds for gas * price + value"), ErrClassInsufficientFunds},
+		{fmt.Errorf("Post \"http://localhost:8545\": %w", syscall.ECONNRESET), ErrClassConnectionReset},
+		{fmt.Errorf("Post \"http://localhost:8545\": %w", io.EOF), ErrClassConnectionReset},
+		{context.DeadlineExceeded, ErrClassTimeout},
+		{errors.New("i/o timeout"), ErrClassTimeout},
+		{errors.New("execution reverted"), ErrClassOther},
+	}
+	for _, test := range tests {
+		if got := classifyError(test.err); got != test.want {
+			t.Errorf("classifyError(%q) = %s, want %s", test.err, got, test.want)
+		}
+	}
+}
+
+func TestNewErrorPolicy(t *testing.T) {
+	errPolicy, err := newErrorPolicy(&config.ErrorConfig{
+		Policies: map[string]string{ErrClassInsufficientFunds: ErrPolicyAbort},
+		Retries:  2,
+		Backoff:  "10ms",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy := errPolicy.policy(ErrClassInsufficientFunds); policy != ErrPolicyAbort {
+		t.Errorf("insufficientFunds policy = %s, want abort", policy)
+	}
+	if policy := errPolicy.policy(ErrClassNonceTooLow); policy != ErrPolicyResync {
+		t.Errorf("nonceTooLow policy = %s, want the default resync", policy)
+	}
+
+	for retry, want := range []int64{10, 20} {
+		delay, ok := errPolicy.retryDelay(ErrClassTxPoolFull, retry)
+		if !ok || delay.Milliseconds() != want {
+			t.Errorf("retry %d delay = %s, %v, want %dms", retry, delay, ok, want)
+		}
+	}
+	if _, ok := errPolicy.retryDelay(ErrClassTxPoolFull, 2); ok {
+		t.Error("transaction is retried more than the retries")
+	}
+	if _, ok := errPolicy.retryDelay(ErrClassOther, 0); ok {
+		t.Error("transaction with the skip policy is retried")
+	}
+
+	// the delay of many retries is capped instead of overflowing.
+	errPolicy, err = newErrorPolicy(&config.ErrorConfig{Retries: 100, Backoff: "1s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, retry := range []int{5, 63, 64, 99} {
+		if delay, ok := errPolicy.retryDelay(ErrClassTxPoolFull, retry); !ok || delay != MaxErrorBackoff {
+			t.Errorf("retry %d delay = %s, %v, want %s", retry, delay, ok, MaxErrorBackoff)
+		}
+	}
+	// a backoff longer than the maximum is kept.
+	errPolicy, err = newErrorPolicy(&config.ErrorConfig{Retries: 100, Backoff: "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delay, _ := errPolicy.retryDelay(ErrClassTxPoolFull, 99); delay != time.Minute {
+		t.Errorf("retry delay = %s, want the 1m backoff", delay)
+	}
+
+	invalid := []*config.ErrorConfig{
+		{Policies: map[string]string{"unknown": ErrPolicySkip}},
+		{Policies: map[string]string{ErrClassTimeout: "ignore"}},
+		{Retries: -1},
+		{Backoff: "soon"},
+	}
+	for _, errorConfig := range invalid {
+		if _, err := newErrorPolicy(errorConfig); err == nil {
+			t.Errorf("newErrorPolicy(%+v) didn't fail", errorConfig)
+		}
+	}
+}
+
+// failingTxSender fails the first transactions with the given errors.
+type failingTxSender struct {
+	errs []error
+}
+
+func (f *failingTxSender) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return err
+	}
+	return nil
+}
+
+func TestNodeConnSendTx(t *testing.T) {
+	// the pending nonce of the fake node is 10, the transaction has the
+	// second nonce handed out.
+	tx := types.NewTx(&types.LegacyTx{Nonce: 11, Gas: 21000, GasPrice: big.NewInt(1)})
+
+	tests := []struct {
+		name       string
+		errs       []error
+		wantErr    bool
+		wantNonce  uint64
+		wantErrors map[string]int
+		policies   map[string]string
+	}{
+		{"retried", []error{errors.New("txpool is full"), errors.New("txpool is full")}, false, 12, nil, nil},
+		{"known after retry", []error{errors.New("i/o timeout"), errors.New("already known")}, false, 12, nil, nil},
+		{"retries exhausted", []error{errors.New("txpool is full"), errors.New("txpool is full"), errors.New("txpool is full")}, true, 10,
+			map[string]int{ErrClassTxPoolFull: 1}, nil},
+		{"resynced", []error{errors.New("nonce too low")}, true, 10, map[string]int{ErrClassNonceTooLow: 1}, nil},
+		// the nonce of a skipped transaction is given back.
+		{"skipped", []error{errors.New("insufficient funds")}, true, 11, map[string]int{ErrClassInsufficientFunds: 1}, nil},
+		{"skipped other", []error{errors.New("intrinsic gas too low")}, true, 11, map[string]int{ErrClassOther: 1}, nil},
+		// a skipped transaction that may be in the pool of the node keeps
+		// its nonce, the nonce manager is resynced.
+		{"skipped timeout", []error{errors.New("i/o timeout")}, true, 10, map[string]int{ErrClassTimeout: 1},
+			map[string]string{ErrClassTimeout: ErrPolicySkip}},
+		{"skipped connection reset", []error{errors.New("connection reset by peer")}, true, 10, map[string]int{ErrClassConnectionReset: 1},
+			map[string]string{ErrClassConnectionReset: ErrPolicySkip}},
+	}
+	for _, test := range tests {
+		node := newTestNodeConn(t, "node1", 1)
+		node.errPolicy, _ = newErrorPolicy(&config.ErrorConfig{Retries: 2, Backoff: "1ms", Policies: test.policies})
+		node.results = &logger.TestResults{}
+		node.backend = &failingTxSender{errs: test.errs}
+
+		from := node.senders[0]
+		from.nonces.Next(context.Background())
+		from.nonces.Next(context.Background())
+
+		err := node.sendTx(context.Background(), from, tx)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: sendTx error = %v, want error %v", test.name, err, test.wantErr)
+		}
+		if !test.wantErr && node.results.TotalTxCount != 1 {
+			t.Errorf("%s: %d transactions counted, want 1", test.name, node.results.TotalTxCount)
+		}
+
+		if nonce, _ := from.nonces.Next(context.Background()); nonce != test.wantNonce {
+			t.Errorf("%s: next nonce = %d, want %d", test.name, nonce, test.wantNonce)
+		}
+		if len(node.results.ErrorCounts) != len(test.wantErrors) {
+			t.Errorf("%s: error counts = %v, want %v", test.name, node.results.ErrorCounts, test.wantErrors)
+		}
+		for class, count := range test.wantErrors {
+			if node.results.ErrorCounts[class] != count {
+				t.Errorf("%s: %s error count = %d, want %d", test.name, class, node.results.ErrorCounts[class], count)
+			}
+		}
+	}
+}
+
+func TestNodeConnSendTxInterrupted(t *testing.T) {
+	node := newTestNodeConn(t, "node1", 1)
+	node.errPolicy, _ = newErrorPolicy(&config.ErrorConfig{Retries: 2, Backoff: "1h"})
+	node.results = &logger.TestResults{}
+	node.backend = &failingTxSender{errs: []error{errors.New("txpool is full")}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// the retry backoff is interrupted instead of sleeping for an hour.
+	tx := types.NewTx(&types.LegacyTx{Gas: 21000, GasPrice: big.NewInt(1)})
+	if err := node.sendTx(ctx, node.senders[0], tx); err != context.DeadlineExceeded {
+		t.Errorf("sendTx error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if len(node.results.ErrorCounts) != 0 {
+		t.Errorf("interrupted transaction is counted as failed: %v", node.results.ErrorCounts)
+	}
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Failure policies of a test profile, they decide what happens when a node
+// of the profile fails.
+const (
+	// FailurePolicyContinue stops the test profile and continues the run
+	// with the other test profiles.
+	FailurePolicyContinue = "continue"
+	// FailurePolicySkipNode stops the failed node and continues the test
+	// profile with the other nodes.
+	FailurePolicySkipNode = "skipNode"
+	// FailurePolicyAbort stops the run.
+	FailurePolicyAbort = "abort"
+)
+
+// DefaultGracePeriod is how long the receipts of the sent transactions are
+// waited for after the test is interrupted.
+const DefaultGracePeriod = 30 * time.Second
+
+// ErrInterrupted is returned when the test is interrupted before it is
+// finished.
+var ErrInterrupted = errors.New("test is interrupted")
+
+// parseFailurePolicy validates the failure policy of a test profile, the
+// default is abort.
+func parseFailurePolicy(policy string) (string, error) {
+	switch policy {
+	case "":
+		return FailurePolicyAbort, nil
+	case FailurePolicyContinue, FailurePolicySkipNode, FailurePolicyAbort:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown failure policy: %q", policy)
+}
+
+// abortError is returned for a transaction error whose error class has the
+// abort policy, it fails the node of the transaction.
+type abortError struct {
+	class string
+	err   error
+}
+
+func (e *abortError) Error() string {
+	return fmt.Sprintf("%s error: %v", e.class, e.err)
+}
+
+// stopper is the context of a run, a test profile or a node that is
+// cancelled with the first error it is stopped with. cancel has to be called
+// when the stopper is no longer used.
+type stopper struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	stopped bool
+	err     error
+}
+
+func newStopper(parent context.Context) *stopper {
+	ctx, cancel := context.WithCancel(parent)
+	return &stopper{ctx: ctx, cancel: cancel}
+}
+
+// stop cancels the context with the given error, only the first error is
+// kept. Stopping with a nil error stops without a failure.
+func (s *stopper) stop(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.stopped {
+		s.stopped = true
+		s.err = err
+		s.cancel()
+	}
+}
+
+// Err returns the error the stopper is stopped with, nil if it isn't
+// stopped.
+func (s *stopper) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// nodeFailed handles the failure of the given node according to the failure
+// policy of its test profile. It returns nil if the node is skipped,
+// otherwise the error that fails the test profile.
+func nodeFailed(policy, nodeName string, err error) error {
+	err = fmt.Errorf("[%s] node failed: %v", nodeName, err)
+	if policy == FailurePolicySkipNode {
+		log.Errorf("%v, skipping the node.", err)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/logger"
+)
+
+func TestParseFailurePolicy(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   string
+		err    bool
+	}{
+		{"", FailurePolicyAbort, false},
+		{"continue", FailurePolicyContinue, false},
+		{"skipNode", FailurePolicySkipNode, false},
+		{"abort", FailurePolicyAbort, false},
+		{"stop", "", true},
+	}
+	for _, test := range tests {
+		policy, err := parseFailurePolicy(test.policy)
+		if (err != nil) != test.err || policy != test.want {
+			t.Errorf("parseFailurePolicy(%q) = %q, %v", test.policy, policy, err)
+		}
+	}
+}
+
+func TestStopper(t *testing.T) {
+	parent := newStopper(context.Background())
+	defer parent.cancel()
+	child := newStopper(parent.ctx)
+	defer child.cancel()
+
+	first, second := errors.New("first"), errors.New("second")
+	parent.stop(first)
+	parent.stop(second)
+	if err := parent.Err(); err != first {
+		t.Errorf("Err() = %v, want the first error", err)
+	}
+
+	// a child is cancelled with its parent but it isn't failed.
+	if child.ctx.Err() == nil {
+		t.Error("child context isn't cancelled")
+	}
+	if err := child.Err(); err != nil {
+		t.Errorf("child Err() = %v, want nil", err)
+	}
+}
+
+func TestNodeFailed(t *testing.T) {
+	err := errors.New("connection refused")
+	if nodeFailed(FailurePolicySkipNode, "node1", err) != nil {
+		t.Error("skipped node failed the test profile")
+	}
+	for _, policy := range []string{FailurePolicyContinue, FailurePolicyAbort} {
+		if nodeFailed(policy, "node1", err) == nil {
+			t.Errorf("failed node didn't fail the test profile with %s policy", policy)
+		}
+	}
+}
+
+func TestRunNodeLoadStopsOnAbort(t *testing.T) {
+	d := NewDeployClient(logger.NewLogClient(nil))
+	d.Logger.TestResult = &logger.TestResults{}
+	testProfile := &config.TestProfile{Name: "test"}
+	nodeConfig := &config.NodeConfig{Name: "node1", DeployCounts: []int{10, 10}}
+
+	sent := 0
+	err := d.runNodeLoad(context.Background(), testProfile, nodeConfig, 0, func(context.Context) error {
+		sent++
+		if sent == 3 {
+			return &abortError{class: ErrClassInsufficientFunds, err: errors.New("insufficient funds")}
+		}
+		// other errors are handled by the error policy.
+		return errors.New("nonce too low")
+	})
+	var abortErr *abortError
+	if !errors.As(err, &abortErr) {
+		t.Errorf("runNodeLoad error = %v, want an abortError", err)
+	}
+	if sent != 3 {
+		t.Errorf("sent %d transactions, want 3", sent)
+	}
+}
+
+func TestDeployTestProfilesInterrupted(t *testing.T) {
+	d := NewDeployClient(logger.NewLogClient(nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := d.DeployTestProfiles(ctx, []config.TestProfile{{Name: "test"}}, false)
+	if err != ErrInterrupted {
+		t.Errorf("DeployTestProfiles error = %v, want ErrInterrupted", err)
+	}
+	if !d.Logger.TestResult.Interrupted {
+		t.Error("test results aren't marked as interrupted")
+	}
+}
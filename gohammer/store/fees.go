@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+const (
+	// FeeSuggested uses the suggested priority fee of the node.
+	FeeSuggested = "suggested"
+
+	DefaultMaxFee = "2x"
+)
+
+// feeSource returns the fee values of a node, ethclient.Client satisfies it.
+type feeSource interface {
+	gasPriceSource
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// txFees are the fees of a transaction. GasPrice is set for legacy and
+// access list transactions, GasFeeCap and GasTipCap for dynamic fee
+// transactions.
+type txFees struct {
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+}
+
+// feeStrategy returns the fees of the transactions of a node according to
+// their type. The values fetched from the node are cached for the gas price
+// refresh interval.
+type feeStrategy struct {
+	txType string
+
+	gasPrices *GasPriceCache
+
+	// tipCap is the fixed priority fee, tipCaps are used if it is nil.
+	tipCap  *big.Int
+	tipCaps *bigIntCache
+
+	// feeCap is the fixed max fee, if it is nil the max fee is
+	// baseFeeMultiplier (in thousandths) times the base fee plus the
+	// priority fee.
+	feeCap            *big.Int
+	baseFeeMultiplier int64
+	baseFees          *bigIntCache
+}
+
+func newFeeStrategy(txType string, feeConfig *config.FeeConfig, source feeSource, interval time.Duration) (*feeStrategy, error) {
+	f := &feeStrategy{
+		txType:    txType,
+		gasPrices: NewGasPriceCache(source, interval),
+	}
+	if txType != TxTypeDynamicFee {
+		if feeConfig != nil {
+			return nil, errors.New("fees can only be set for dynamicFee transactions")
+		}
+		return f, nil
+	}
+
+	if feeConfig == nil {
+		feeConfig = &config.FeeConfig{}
+	}
+
+	priorityFee := feeConfig.PriorityFee
+	if priorityFee == "" || priorityFee == FeeSuggested {
+		f.tipCaps = &bigIntCache{fetch: source.SuggestGasTipCap, interval: interval}
+	} else {
+		tipCap, err := parseFee(priorityFee)
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority fee: %v", err)
+		}
+		f.tipCap = tipCap
+	}
+
+	maxFee := feeConfig.MaxFee
+	if maxFee == "" {
+		maxFee = DefaultMaxFee
+	}
+	if strings.HasSuffix(maxFee, "x") {
+		multiplier, err := strconv.ParseFloat(strings.TrimSuffix(maxFee, "x"), 64)
+		if err != nil || multiplier <= 0 {
+			return nil, fmt.Errorf("invalid max fee multiplier: %q", maxFee)
+		}
+		f.baseFeeMultiplier = int64(multiplier * 1000)
+		f.baseFees = &bigIntCache{
+			fetch: func(ctx context.Context) (*big.Int, error) {
+				header, err := source.HeaderByNumber(ctx, nil)
+				if err != nil {
+					return nil, err
+				}
+				if header.BaseFee == nil {
+					return nil, errors.New("latest block has no base fee, the node doesn't support dynamic fee transactions")
+				}
+				return header.BaseFee, nil
+			},
+			interval: interval,
+		}
+	} else {
+		feeCap, err := parseFee(maxFee)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max fee: %v", err)
+		}
+		f.feeCap = feeCap
+	}
+
+	if f.feeCap != nil && f.tipCap != nil && f.feeCap.Cmp(f.tipCap) < 0 {
+		return nil, fmt.Errorf("max fee (%s) is less than priority fee (%s)", f.feeCap, f.tipCap)
+	}
+	return f, nil
+}
+
+// parseFee parses a non-negative wei amount.
+func parseFee(str string) (*big.Int, error) {
+	fee, err := toBigInt(str)
+	if err != nil {
+		return nil, err
+	}
+	if fee.Sign() < 0 {
+		return nil, fmt.Errorf("%s is negative", fee)
+	}
+	return fee, nil
+}
+
+// get returns the fees of the next transaction.
+func (f *feeStrategy) get(ctx context.Context) (*txFees, error) {
+	if f.txType != TxTypeDynamicFee {
+		gasPrice, err := f.gasPrices.Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &txFees{GasPrice: gasPrice}, nil
+	}
+
+	tipCap := f.tipCap
+	if tipCap == nil {
+		var err error
+		tipCap, err = f.tipCaps.get(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	feeCap := f.feeCap
+	if feeCap == nil {
+		baseFee, err := f.baseFees.get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		feeCap = baseFee.Mul(baseFee, big.NewInt(f.baseFeeMultiplier))
+		feeCap.Div(feeCap, big.NewInt(1000))
+		feeCap.Add(feeCap, tipCap)
+	} else if feeCap.Cmp(tipCap) < 0 {
+		// a suggested priority fee can't be more than the fixed max fee.
+		tipCap = feeCap
+	}
+
+	return &txFees{GasFeeCap: feeCap, GasTipCap: tipCap}, nil
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+func TestFeeStrategy(t *testing.T) {
+	source := &fakeNode{tipCap: big.NewInt(100), baseFee: big.NewInt(1000)}
+
+	tests := []struct {
+		txType    string
+		feeConfig *config.FeeConfig
+		want      txFees
+	}{
+		{TxTypeLegacy, nil, txFees{GasPrice: big.NewInt(1)}},
+		{TxTypeAccessList, nil, txFees{GasPrice: big.NewInt(1)}},
+		{TxTypeDynamicFee, nil, txFees{GasFeeCap: big.NewInt(2100), GasTipCap: big.NewInt(100)}},
+		{TxTypeDynamicFee, &config.FeeConfig{MaxFee: "1.5x", PriorityFee: "50"}, txFees{GasFeeCap: big.NewInt(1550), GasTipCap: big.NewInt(50)}},
+		{TxTypeDynamicFee, &config.FeeConfig{MaxFee: "5000", PriorityFee: FeeSuggested}, txFees{GasFeeCap: big.NewInt(5000), GasTipCap: big.NewInt(100)}},
+		// the suggested priority fee is capped by the fixed max fee.
+		{TxTypeDynamicFee, &config.FeeConfig{MaxFee: "80"}, txFees{GasFeeCap: big.NewInt(80), GasTipCap: big.NewInt(80)}},
+	}
+	for _, test := range tests {
+		source.gasCalls = 0
+		fees, err := newFeeStrategy(test.txType, test.feeConfig, source, time.Minute)
+		if err != nil {
+			t.Fatalf("%s %+v: %v", test.txType, test.feeConfig, err)
+		}
+		got, err := fees.get(context.Background())
+		if err != nil {
+			t.Fatalf("%s %+v: %v", test.txType, test.feeConfig, err)
+		}
+		if !equalBigInt(got.GasPrice, test.want.GasPrice) || !equalBigInt(got.GasFeeCap, test.want.GasFeeCap) ||
+			!equalBigInt(got.GasTipCap, test.want.GasTipCap) {
+			t.Errorf("%s %+v: fees = %+v, want %+v", test.txType, test.feeConfig, got, test.want)
+		}
+	}
+}
+
+func TestFeeStrategyErrors(t *testing.T) {
+	source := &fakeNode{tipCap: big.NewInt(100)}
+
+	invalid := []*config.FeeConfig{
+		{MaxFee: "0x"},
+		{MaxFee: "ax"},
+		{MaxFee: "-1"},
+		{PriorityFee: "fast"},
+		{MaxFee: "10", PriorityFee: "20"},
+	}
+	for _, feeConfig := range invalid {
+		if _, err := newFeeStrategy(TxTypeDynamicFee, feeConfig, source, time.Minute); err == nil {
+			t.Errorf("newFeeStrategy(%+v) didn't fail", feeConfig)
+		}
+	}
+	if _, err := newFeeStrategy(TxTypeLegacy, &config.FeeConfig{}, source, time.Minute); err == nil {
+		t.Error("newFeeStrategy didn't fail on fees of legacy transactions")
+	}
+
+	// the node doesn't have a base fee.
+	fees, err := newFeeStrategy(TxTypeDynamicFee, nil, source, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fees.get(context.Background()); err == nil {
+		t.Error("get didn't fail without a base fee")
+	}
+}
+
+func equalBigInt(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+// Gas policies.
+const (
+	GasPolicyFixed        = "fixed"
+	GasPolicyEstimateOnce = "estimateOnce"
+	GasPolicyEstimate     = "estimate"
+)
+
+// DefaultGasLimit is the gas limit of contract transactions if the gas
+// policy doesn't have a limit.
+const DefaultGasLimit = 300000
+
+// gasEstimator estimates the gas of a transaction, ethclient.Client
+// satisfies it.
+type gasEstimator interface {
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+}
+
+// gasPolicy returns the gas limit of the transactions of a node.
+type gasPolicy struct {
+	policy     string
+	limit      uint64
+	multiplier float64
+	source     gasEstimator
+
+	mu sync.Mutex
+	// estimates are the estimates of the estimateOnce policy, deploys,
+	// transfers and the calls of every method are estimated separately so
+	// a mixed workload doesn't share one estimate.
+	estimates map[gasEstimateKey]uint64
+}
+
+// gasEstimateKey identifies the transactions that share an estimate of the
+// estimateOnce policy. selector is the method selector of a call, it is
+// empty for transfers.
+type gasEstimateKey struct {
+	creation bool
+	selector string
+}
+
+func newGasEstimateKey(msg ethereum.CallMsg) gasEstimateKey {
+	if msg.To == nil {
+		return gasEstimateKey{creation: true}
+	}
+	selector := msg.Data
+	if len(selector) > 4 {
+		selector = selector[:4]
+	}
+	return gasEstimateKey{selector: string(selector)}
+}
+
+func newGasPolicy(gasConfig *config.GasConfig, source gasEstimator) (*gasPolicy, error) {
+	if gasConfig == nil {
+		gasConfig = &config.GasConfig{}
+	}
+
+	g := &gasPolicy{
+		policy:     gasConfig.Policy,
+		limit:      gasConfig.Limit,
+		multiplier: gasConfig.Multiplier,
+		source:     source,
+		estimates:  make(map[gasEstimateKey]uint64),
+	}
+	if g.policy == "" {
+		g.policy = GasPolicyFixed
+	}
+	if g.multiplier == 0 {
+		g.multiplier = 1
+	}
+
+	switch g.policy {
+	case GasPolicyFixed:
+		if gasConfig.Multiplier != 0 {
+			return nil, errors.New("multiplier can't be used with the fixed gas policy")
+		}
+	case GasPolicyEstimateOnce, GasPolicyEstimate:
+		if gasConfig.Limit != 0 {
+			return nil, fmt.Errorf("limit can't be used with the %s gas policy", g.policy)
+		}
+		if g.multiplier < 1 {
+			return nil, fmt.Errorf("gas multiplier can't be less than 1: %v", g.multiplier)
+		}
+	default:
+		return nil, fmt.Errorf("unknown gas policy: %q", g.policy)
+	}
+	return g, nil
+}
+
+// gasLimit returns the gas limit of the given transaction, defaultLimit is
+// the gas limit of the workload.
+func (g *gasPolicy) gasLimit(ctx context.Context, msg ethereum.CallMsg, defaultLimit uint64) (uint64, error) {
+	switch g.policy {
+	case GasPolicyEstimateOnce:
+		key := newGasEstimateKey(msg)
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if estimate, ok := g.estimates[key]; ok {
+			return estimate, nil
+		}
+		estimate, err := g.estimate(ctx, msg)
+		if err != nil {
+			return 0, err
+		}
+		g.estimates[key] = estimate
+		return estimate, nil
+
+	case GasPolicyEstimate:
+		return g.estimate(ctx, msg)
+	}
+
+	if g.limit != 0 {
+		return g.limit, nil
+	}
+	return defaultLimit, nil
+}
+
+func (g *gasPolicy) estimate(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	estimate, err := g.source.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, fmt.Errorf("Error while estimating gas: %v", err)
+	}
+	return uint64(float64(estimate) * g.multiplier), nil
+}
+
+// isOutOfGasError reports whether the node rejected a transaction because of
+// its gas limit.
+func isOutOfGasError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "intrinsic gas too low") ||
+		strings.Contains(msg, "out of gas") ||
+		strings.Contains(msg, "gas required exceeds allowance")
+}
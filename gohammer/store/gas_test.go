@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+type fakeEstimator struct {
+	gas   uint64
+	err   error
+	calls int
+}
+
+func (f *fakeEstimator) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	f.calls++
+	if msg.To == nil {
+		return f.gas * 10, f.err
+	}
+	return f.gas, f.err
+}
+
+func TestGasPolicyFixed(t *testing.T) {
+	gas, err := newGasPolicy(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit, err := gas.gasLimit(context.Background(), ethereum.CallMsg{}, 21000); err != nil || limit != 21000 {
+		t.Errorf("gasLimit = %d, %v, want the default 21000", limit, err)
+	}
+
+	gas, err = newGasPolicy(&config.GasConfig{Limit: 50000}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit, err := gas.gasLimit(context.Background(), ethereum.CallMsg{}, 21000); err != nil || limit != 50000 {
+		t.Errorf("gasLimit = %d, %v, want 50000", limit, err)
+	}
+}
+
+func TestGasPolicyEstimate(t *testing.T) {
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	call := ethereum.CallMsg{To: &to}
+	creation := ethereum.CallMsg{}
+
+	source := &fakeEstimator{gas: 30000}
+	gas, err := newGasPolicy(&config.GasConfig{Policy: GasPolicyEstimateOnce, Multiplier: 1.5}, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if limit, err := gas.gasLimit(context.Background(), call, 0); err != nil || limit != 45000 {
+			t.Errorf("call gasLimit = %d, %v, want 45000", limit, err)
+		}
+		if limit, err := gas.gasLimit(context.Background(), creation, 0); err != nil || limit != 450000 {
+			t.Errorf("creation gasLimit = %d, %v, want 450000", limit, err)
+		}
+	}
+	if source.calls != 2 {
+		t.Errorf("gas estimated %d times, want 2", source.calls)
+	}
+
+	source = &fakeEstimator{gas: 30000}
+	gas, err = newGasPolicy(&config.GasConfig{Policy: GasPolicyEstimate}, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if limit, err := gas.gasLimit(context.Background(), call, 0); err != nil || limit != 30000 {
+			t.Errorf("gasLimit = %d, %v, want 30000", limit, err)
+		}
+	}
+	if source.calls != 3 {
+		t.Errorf("gas estimated %d times, want 3", source.calls)
+	}
+}
+
+func TestGasPolicyEstimationError(t *testing.T) {
+	source := &fakeEstimator{err: errors.New("execution reverted")}
+	gas, err := newGasPolicy(&config.GasConfig{Policy: GasPolicyEstimate}, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gas.gasLimit(context.Background(), ethereum.CallMsg{}, 0); err == nil {
+		t.Fatal("gasLimit didn't fail")
+	}
+}
+
+// workloadEstimator estimates the gas of a transfer, a setItem call and a
+// deploy like a node would.
+type workloadEstimator struct{}
+
+func (workloadEstimator) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	switch {
+	case msg.To == nil:
+		return 500000, nil
+	case len(msg.Data) == 0:
+		return 21000, nil
+	}
+	return 45000, nil
+}
+
+func TestGasPolicyEstimateOnceMix(t *testing.T) {
+	gas, err := newGasPolicy(&config.GasConfig{Policy: GasPolicyEstimateOnce}, workloadEstimator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mix, err := newTxMix(&config.MixConfig{Deploy: 20, Call: 40, Transfer: 40, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contract, err := NewStoreContract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	setItem, err := contract.ABI.Pack("setItem", [32]byte{1}, [32]byte{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	want := map[string]uint64{WorkloadDeploy: 500000, WorkloadCall: 45000, WorkloadTransfer: 21000}
+	msgs := map[string]ethereum.CallMsg{
+		WorkloadDeploy:   {Data: contract.Bytecode},
+		WorkloadCall:     {To: &to, Data: setItem},
+		WorkloadTransfer: {To: &to},
+	}
+	for i := 0; i < 50; i++ {
+		workload := mix.next()
+		limit, err := gas.gasLimit(context.Background(), msgs[workload], 0)
+		if err != nil || limit != want[workload] {
+			t.Fatalf("%s gasLimit = %d, %v, want %d", workload, limit, err, want[workload])
+		}
+	}
+}
+
+func TestNewGasPolicyInvalid(t *testing.T) {
+	invalid := []*config.GasConfig{
+		{Policy: "guess"},
+		{Multiplier: 1.2},
+		{Policy: GasPolicyEstimate, Limit: 50000},
+		{Policy: GasPolicyEstimateOnce, Multiplier: 0.5},
+	}
+	for _, gasConfig := range invalid {
+		if _, err := newGasPolicy(gasConfig, nil); err == nil {
+			t.Errorf("newGasPolicy(%+v) didn't fail", gasConfig)
+		}
+	}
+}
+
+func TestIsOutOfGasError(t *testing.T) {
+	if !isOutOfGasError(errors.New("intrinsic gas too low")) {
+		t.Error("intrinsic gas too low is not an out of gas error")
+	}
+	if isOutOfGasError(errors.New("nonce too low")) || isOutOfGasError(nil) {
+		t.Error("unrelated error is an out of gas error")
+	}
+}
@@ -0,0 +1,263 @@
+package store
+
+import (
+	crand "crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Argument generator types, a contract argument is generated for every
+// transaction if it is given as a JSON object with a "generator" key.
+const (
+	GeneratorCounter     = "counter"
+	GeneratorRandomBytes = "randomBytes"
+	GeneratorRandom      = "random"
+	GeneratorPick        = "pick"
+	GeneratorTemplate    = "template"
+	GeneratorUUID        = "uuid"
+)
+
+// argContext contains the transaction specific values that the argument
+// generators can use.
+type argContext struct {
+	node   string
+	sender common.Address
+	nonce  uint64
+	index  uint64
+}
+
+// argGenerator returns the value of a contract argument for a transaction,
+// the value is converted to the ABI type of the argument afterwards.
+type argGenerator interface {
+	generate(ctx *argContext) (interface{}, error)
+}
+
+// lockedRand is a math/rand source that is safe for concurrent use. It is
+// shared by the generators of a contract so a seed reproduces the same
+// sequence of arguments.
+type lockedRand struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newLockedRand(seed int64) *lockedRand {
+	return &lockedRand{rand: rand.New(rand.NewSource(seed))}
+}
+
+func (r *lockedRand) read(size int) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data := make([]byte, size)
+	r.rand.Read(data)
+	return data
+}
+
+func (r *lockedRand) bigInt(max *big.Int) *big.Int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return new(big.Int).Rand(r.rand, max)
+}
+
+func (r *lockedRand) float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Float64()
+}
+
+func (r *lockedRand) intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Intn(n)
+}
+
+// newArgGenerator returns the generator of the given config value. Values
+// which are not generator specs are returned as they are for every
+// transaction.
+func newArgGenerator(value interface{}, random *lockedRand) (argGenerator, error) {
+	spec, ok := value.(map[string]interface{})
+	if !ok {
+		return constGenerator{value}, nil
+	}
+
+	generatorType, _ := spec["generator"].(string)
+	switch generatorType {
+	case GeneratorCounter:
+		start, err := specBigInt(spec, "start", big.NewInt(0))
+		if err != nil {
+			return nil, err
+		}
+		step, err := specBigInt(spec, "step", big.NewInt(1))
+		if err != nil {
+			return nil, err
+		}
+		return &counterGenerator{next: start, step: step}, nil
+
+	case GeneratorRandomBytes:
+		size, err := specBigInt(spec, "size", big.NewInt(32))
+		if err != nil {
+			return nil, err
+		}
+		if size.Sign() <= 0 || !size.IsInt64() {
+			return nil, fmt.Errorf("invalid randomBytes size: %s", size)
+		}
+		return &randomBytesGenerator{size: int(size.Int64()), random: random}, nil
+
+	case GeneratorRandom:
+		min, err := specBigInt(spec, "min", big.NewInt(0))
+		if err != nil {
+			return nil, err
+		}
+		max, err := specBigInt(spec, "max", nil)
+		if err != nil {
+			return nil, err
+		}
+		if max == nil || max.Cmp(min) < 0 {
+			return nil, errors.New("random generator must have a max that is not less than min")
+		}
+		return &randomGenerator{min: min, max: max, random: random}, nil
+
+	case GeneratorPick:
+		values, _ := spec["values"].([]interface{})
+		if len(values) == 0 {
+			return nil, errors.New("pick generator must have values")
+		}
+		generator := &pickGenerator{values: values}
+		if pickRandom, _ := spec["random"].(bool); pickRandom {
+			generator.random = random
+		}
+		return generator, nil
+
+	case GeneratorTemplate:
+		template, ok := spec["template"].(string)
+		if !ok {
+			return nil, errors.New("template generator must have a template")
+		}
+		return templateGenerator{template: template}, nil
+
+	case GeneratorUUID:
+		return uuidGenerator{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown argument generator: %q", generatorType)
+}
+
+// specBigInt returns the integer value of the given key of a generator spec,
+// or def if the key doesn't exist.
+func specBigInt(spec map[string]interface{}, key string, def *big.Int) (*big.Int, error) {
+	value, ok := spec[key]
+	if !ok {
+		return def, nil
+	}
+	n, err := toBigInt(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return n, nil
+}
+
+// constGenerator returns the same value for every transaction.
+type constGenerator struct {
+	value interface{}
+}
+
+func (g constGenerator) generate(*argContext) (interface{}, error) {
+	return g.value, nil
+}
+
+// counterGenerator returns start, start+step, start+2*step...
+type counterGenerator struct {
+	mu   sync.Mutex
+	next *big.Int
+	step *big.Int
+}
+
+func (g *counterGenerator) generate(*argContext) (interface{}, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	value := new(big.Int).Set(g.next)
+	g.next.Add(g.next, g.step)
+	return value, nil
+}
+
+// randomBytesGenerator returns random bytes of the given size.
+type randomBytesGenerator struct {
+	size   int
+	random *lockedRand
+}
+
+func (g *randomBytesGenerator) generate(*argContext) (interface{}, error) {
+	return g.random.read(g.size), nil
+}
+
+// randomGenerator returns a random integer in [min, max].
+type randomGenerator struct {
+	min    *big.Int
+	max    *big.Int
+	random *lockedRand
+}
+
+func (g *randomGenerator) generate(*argContext) (interface{}, error) {
+	span := new(big.Int).Sub(g.max, g.min)
+	span.Add(span, big.NewInt(1))
+	return new(big.Int).Add(g.min, g.random.bigInt(span)), nil
+}
+
+// pickGenerator returns one of the values, in turn or randomly.
+type pickGenerator struct {
+	values []interface{}
+	random *lockedRand
+}
+
+func (g *pickGenerator) generate(ctx *argContext) (interface{}, error) {
+	if g.random != nil {
+		return g.values[g.random.intn(len(g.values))], nil
+	}
+	return g.values[ctx.index%uint64(len(g.values))], nil
+}
+
+// templateGenerator returns the template with {node}, {sender}, {nonce} and
+// {index} placeholders replaced by the values of the transaction.
+type templateGenerator struct {
+	template string
+}
+
+func (g templateGenerator) generate(ctx *argContext) (interface{}, error) {
+	return strings.NewReplacer(
+		"{node}", ctx.node,
+		"{sender}", ctx.sender.Hex(),
+		"{nonce}", fmt.Sprintf("%d", ctx.nonce),
+		"{index}", fmt.Sprintf("%d", ctx.index),
+	).Replace(g.template), nil
+}
+
+// uuidGenerator returns a random (version 4) UUID, it is converted to its
+// 16 bytes for bytes arguments and to its string form for string arguments.
+// UUIDs are read from crypto/rand instead of the seeded source, so they are
+// unique across runs and keys written by a previous run aren't overwritten.
+type uuidGenerator struct{}
+
+func (uuidGenerator) generate(*argContext) (interface{}, error) {
+	var id uuid
+	if _, err := crand.Read(id[:]); err != nil {
+		return nil, fmt.Errorf("Error while generating uuid: %v", err)
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return id, nil
+}
+
+type uuid [16]byte
+
+func (u uuid) Bytes() []byte {
+	return u[:]
+}
+
+func (u uuid) String() string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
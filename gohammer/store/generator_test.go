@@ -0,0 +1,161 @@
+package store
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestArgGenerators(t *testing.T) {
+	ctx := &argContext{
+		node:   "node1",
+		sender: common.HexToAddress("0x00000000000000000000000000000000000000aa"),
+		nonce:  7,
+		index:  3,
+	}
+
+	tests := []struct {
+		spec interface{}
+		want []interface{}
+	}{
+		{"0x01", []interface{}{"0x01", "0x01"}},
+		{
+			map[string]interface{}{"generator": "counter", "start": float64(5), "step": float64(2)},
+			[]interface{}{big.NewInt(5), big.NewInt(7), big.NewInt(9)},
+		},
+		{
+			map[string]interface{}{"generator": "pick", "values": []interface{}{"a", "b"}},
+			[]interface{}{"b", "b"},
+		},
+		{
+			map[string]interface{}{"generator": "template", "template": "{node}-{sender}-{nonce}-{index}"},
+			[]interface{}{"node1-0x00000000000000000000000000000000000000AA-7-3"},
+		},
+	}
+
+	for _, test := range tests {
+		generator, err := newArgGenerator(test.spec, newLockedRand(1))
+		if err != nil {
+			t.Fatalf("newArgGenerator(%v): %v", test.spec, err)
+		}
+		for i, want := range test.want {
+			got, err := generator.generate(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%v: value %d = %v, want %v", test.spec, i, got, want)
+			}
+		}
+	}
+}
+
+func TestArgGeneratorErrors(t *testing.T) {
+	specs := []map[string]interface{}{
+		{"generator": "unknown"},
+		{"generator": "random", "min": float64(10), "max": float64(1)},
+		{"generator": "random"},
+		{"generator": "randomBytes", "size": float64(0)},
+		{"generator": "pick"},
+		{"generator": "template"},
+	}
+	for _, spec := range specs {
+		if _, err := newArgGenerator(spec, newLockedRand(1)); err == nil {
+			t.Errorf("newArgGenerator(%v) didn't fail", spec)
+		}
+	}
+}
+
+func TestRandomGeneratorsAreSeeded(t *testing.T) {
+	generate := func(seed int64) []interface{} {
+		random := newLockedRand(seed)
+		var values []interface{}
+		for _, spec := range []map[string]interface{}{
+			{"generator": "random", "min": float64(10), "max": float64(20)},
+			{"generator": "randomBytes", "size": float64(8)},
+		} {
+			generator, err := newArgGenerator(spec, random)
+			if err != nil {
+				t.Fatal(err)
+			}
+			value, err := generator.generate(&argContext{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			values = append(values, value)
+		}
+		return values
+	}
+
+	first, second := generate(42), generate(42)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("same seed generated %v and %v", first, second)
+	}
+	if n := first[0].(*big.Int); n.Cmp(big.NewInt(10)) < 0 || n.Cmp(big.NewInt(20)) > 0 {
+		t.Errorf("random value %s is out of range", n)
+	}
+}
+
+func TestUUIDGeneratorIsNotSeeded(t *testing.T) {
+	generate := func() uuid {
+		generator, err := newArgGenerator(map[string]interface{}{"generator": "uuid"}, newLockedRand(42))
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, err := generator.generate(&argContext{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value.(uuid)
+	}
+
+	first, second := generate(), generate()
+	if first == second {
+		t.Errorf("same seed generated the same UUID %s twice", first)
+	}
+	if first[6]>>4 != 4 || first[8]>>6 != 2 {
+		t.Errorf("%s is not a version 4 UUID", first)
+	}
+}
+
+func TestContractArgsGenerate(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"f","inputs":[
+		{"name":"a","type":"bytes32"},
+		{"name":"b","type":"string"},
+		{"name":"c","type":"uint8"}
+	]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args, err := newContractArgs(parsed.Methods["f"].Inputs, []interface{}{
+		map[string]interface{}{"generator": "counter", "start": float64(1)},
+		map[string]interface{}{"generator": "counter", "start": float64(1)},
+		map[string]interface{}{"generator": "counter", "start": float64(255)},
+	}, newLockedRand(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := args.generate(&argContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{[32]byte{31: 1}, "1", uint8(255)}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("generate = %v, want %v", values, want)
+	}
+
+	// the uint8 counter overflows on the second transaction.
+	if _, err := args.generate(&argContext{}); err == nil {
+		t.Error("generate didn't fail on an out of range value")
+	}
+
+	if _, err := newContractArgs(parsed.Methods["f"].Inputs, []interface{}{"0x01", "a", float64(300)}, newLockedRand(1)); err == nil {
+		t.Error("newContractArgs didn't fail on an out of range constant")
+	}
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// DefaultDerivationPath is the base derivation path of the mnemonic sender
+// accounts, the account index is appended to it (m/44'/60'/0'/0/<index>).
+const DefaultDerivationPath = "m/44'/60'/0'/0"
+
+// deriveMnemonicKeys derives count private keys from the given BIP-39
+// mnemonic along the BIP-32 base derivation path. The words and the checksum
+// of the mnemonic are verified, so a mistyped mnemonic fails instead of
+// deriving unfunded accounts.
+func deriveMnemonicKeys(mnemonic, passphrase, basePath string, count int) ([]*ecdsa.PrivateKey, error) {
+	if count <= 0 {
+		return nil, errors.New("mnemonic account count must be positive")
+	}
+	if basePath == "" {
+		basePath = DefaultDerivationPath
+	}
+	path, err := accounts.ParseDerivationPath(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid derivation path: %v", err)
+	}
+
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	if _, err := bip39.EntropyFromMnemonic(mnemonic); err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %v", err)
+	}
+	seed := pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+passphrase), 2048, 64, sha512.New)
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	master := mac.Sum(nil)
+
+	key, chainCode := master[:32], master[32:]
+	for _, index := range path {
+		key, chainCode, err = deriveChildKey(key, chainCode, index)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var keys []*ecdsa.PrivateKey
+	for i := 0; i < count; i++ {
+		childKey, _, err := deriveChildKey(key, chainCode, uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := crypto.ToECDSA(childKey)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, privateKey)
+	}
+	return keys, nil
+}
+
+// deriveChildKey derives the BIP-32 child private key and chain code at the
+// given index from the parent key and chain code.
+func deriveChildKey(key, chainCode []byte, index uint32) ([]byte, []byte, error) {
+	var data []byte
+	if index >= 0x80000000 {
+		data = append([]byte{0}, key...)
+	} else {
+		parent, err := crypto.ToECDSA(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = crypto.CompressPubkey(&parent.PublicKey)
+	}
+	indexBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(indexBytes, index)
+	data = append(data, indexBytes...)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	curveOrder := crypto.S256().Params().N
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(curveOrder) >= 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+	child := tweak.Add(tweak, new(big.Int).SetBytes(key))
+	child.Mod(child, curveOrder)
+	if child.Sign() == 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+
+	childKey := make([]byte, 32)
+	child.FillBytes(childKey)
+	return childKey, sum[32:], nil
+}
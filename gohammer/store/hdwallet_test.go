@@ -0,0 +1,48 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestDeriveMnemonicKeys(t *testing.T) {
+	// well known development mnemonic and its first accounts.
+	mnemonic := "test test test test test test test test test test test junk"
+	want := []string{
+		"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		"0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+		"0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC",
+	}
+
+	keys, err := deriveMnemonicKeys(mnemonic, "", "", len(want))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != len(want) {
+		t.Fatalf("derived %d keys, want %d", len(keys), len(want))
+	}
+	for i, key := range keys {
+		if got := crypto.PubkeyToAddress(key.PublicKey).Hex(); got != want[i] {
+			t.Errorf("account %d = %s, want %s", i, got, want[i])
+		}
+	}
+
+	if _, err := deriveMnemonicKeys(mnemonic, "", "not a path", 1); err == nil {
+		t.Errorf("expected error for invalid derivation path")
+	}
+
+	invalid := []string{
+		// checksum mismatch, the last word is mistyped.
+		"test test test test test test test test test test test test",
+		// word that isn't in the word list.
+		"test test test test test test test test test test test junkk",
+		// wrong word count.
+		"test test test test test test test test test test junk",
+	}
+	for _, mnemonic := range invalid {
+		if _, err := deriveMnemonicKeys(mnemonic, "", "", 1); err == nil {
+			t.Errorf("deriveMnemonicKeys(%q) didn't fail", mnemonic)
+		}
+	}
+}
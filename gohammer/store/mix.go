@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+// Transaction types of a mixed workload.
+const (
+	WorkloadDeploy   = "deploy"
+	WorkloadCall     = "call"
+	WorkloadTransfer = "transfer"
+)
+
+// txMix draws the transaction types of a mixed workload by their
+// percentages.
+type txMix struct {
+	random *lockedRand
+
+	workloads []string
+	// thresholds are the cumulative percentages of the workloads.
+	thresholds []float64
+}
+
+func newTxMix(mixConfig *config.MixConfig) (*txMix, error) {
+	m := &txMix{random: newLockedRand(mixConfig.Seed)}
+
+	total := 0.0
+	for _, entry := range []struct {
+		workload string
+		percent  float64
+	}{
+		{WorkloadDeploy, mixConfig.Deploy},
+		{WorkloadCall, mixConfig.Call},
+		{WorkloadTransfer, mixConfig.Transfer},
+	} {
+		if entry.percent < 0 {
+			return nil, fmt.Errorf("percentage of %s can't be negative: %v", entry.workload, entry.percent)
+		}
+		if entry.percent == 0 {
+			continue
+		}
+		total += entry.percent
+		m.workloads = append(m.workloads, entry.workload)
+		m.thresholds = append(m.thresholds, total)
+	}
+
+	if len(m.workloads) == 0 {
+		return nil, errors.New("mix has no transaction type")
+	}
+	if math.Abs(total-100) > 1e-9 {
+		return nil, fmt.Errorf("percentages add up to %v instead of 100", total)
+	}
+	return m, nil
+}
+
+// has reports whether the mix has transactions of the given type.
+func (m *txMix) has(workload string) bool {
+	for _, w := range m.workloads {
+		if w == workload {
+			return true
+		}
+	}
+	return false
+}
+
+// next draws the type of the next transaction.
+func (m *txMix) next() string {
+	n := m.random.float64() * 100
+	for i, threshold := range m.thresholds {
+		if n < threshold {
+			return m.workloads[i]
+		}
+	}
+	return m.workloads[len(m.workloads)-1]
+}
+
+// profileMix returns the mixed workload of the given test profile, or nil
+// if it has none.
+func profileMix(testProfile *config.TestProfile) (*txMix, error) {
+	if testProfile.Mix == nil {
+		return nil, nil
+	}
+	if testProfile.PreSign != nil {
+		return nil, errors.New("mixed workloads can't be pre-signed")
+	}
+	mix, err := newTxMix(testProfile.Mix)
+	if err != nil {
+		return nil, fmt.Errorf("Error while parsing mix: %v", err)
+	}
+	return mix, nil
+}
+
+// sendMixTx sends a transaction of the next type of the mix from the given
+// node and counts it by its type. contractAddress is the contract instance
+// of the calls.
+func (d *DeployClient) sendMixTx(ctx context.Context, mix *txMix, node *nodeConn, contractAddress common.Address) error {
+	workload := mix.next()
+
+	var err error
+	switch workload {
+	case WorkloadDeploy:
+		err = deployContract(ctx, node)
+	case WorkloadCall:
+		err = d.callContractMethod(ctx, contractAddress, node)
+	default:
+		err = d.sendTransfer(ctx, node)
+	}
+	node.results.AddWorkloadTx(workload, err != nil)
+	return err
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/logger"
+)
+
+func TestTxMixDistribution(t *testing.T) {
+	mix, err := newTxMix(&config.MixConfig{Call: 60, Transfer: 30, Deploy: 10, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const draws = 10000
+	counts := make(map[string]int)
+	for i := 0; i < draws; i++ {
+		counts[mix.next()]++
+	}
+	for workload, percent := range map[string]float64{WorkloadCall: 60, WorkloadTransfer: 30, WorkloadDeploy: 10} {
+		got := float64(counts[workload]) / draws * 100
+		if got < percent-2 || got > percent+2 {
+			t.Errorf("%s is %.2f%% of the transactions, want %.0f%%", workload, got, percent)
+		}
+	}
+}
+
+func TestTxMixSeed(t *testing.T) {
+	draw := func(seed int64) []string {
+		mix, err := newTxMix(&config.MixConfig{Call: 50, Transfer: 50, Seed: seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var workloads []string
+		for i := 0; i < 20; i++ {
+			workloads = append(workloads, mix.next())
+		}
+		return workloads
+	}
+
+	if !reflect.DeepEqual(draw(7), draw(7)) {
+		t.Error("the same seed drew different transaction types")
+	}
+	if reflect.DeepEqual(draw(7), draw(8)) {
+		t.Error("different seeds drew the same transaction types")
+	}
+}
+
+func TestNewTxMixInvalid(t *testing.T) {
+	invalid := []*config.MixConfig{
+		{},
+		{Call: 60, Transfer: 30},
+		{Call: 110, Transfer: -10},
+	}
+	for _, mixConfig := range invalid {
+		if _, err := newTxMix(mixConfig); err == nil {
+			t.Errorf("newTxMix(%+v) didn't fail", mixConfig)
+		}
+	}
+
+	mix, err := newTxMix(&config.MixConfig{Transfer: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mix.has(WorkloadCall) || !mix.has(WorkloadTransfer) {
+		t.Error("has() doesn't report the transaction types of the mix")
+	}
+}
+
+func TestSendMixTx(t *testing.T) {
+	node := newTestNodeConn(t, "node1", 1)
+	node.results = &logger.TestResults{}
+	node.transfer, _ = LoadTransfer(&config.TransferConfig{})
+	node.backend = &failingTxSender{errs: []error{errors.New("insufficient funds")}}
+
+	mix, err := newTxMix(&config.MixConfig{Transfer: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDeployClient(logger.NewLogClient(nil))
+	for i := 0; i < 3; i++ {
+		d.sendMixTx(context.Background(), mix, node, common.Address{})
+	}
+
+	if node.results.WorkloadTxCounts[WorkloadTransfer] != 2 || node.results.WorkloadErrorCounts[WorkloadTransfer] != 1 {
+		t.Errorf("transfers: %d sent, %d failed, want 2 sent and 1 failed",
+			node.results.WorkloadTxCounts[WorkloadTransfer], node.results.WorkloadErrorCounts[WorkloadTransfer])
+	}
+}
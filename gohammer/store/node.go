@@ -0,0 +1,464 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/logger"
+	"github.com/tubuarge/GoHammer/util"
+)
+
+// nodeConn contains the connection of a node and the sender state that is
+// shared by every transaction sent to the node.
+type nodeConn struct {
+	// nextSenderIndex is the index of the next sender, it is updated
+	// atomically so it is kept as the first field for 64-bit alignment.
+	nextSenderIndex uint64
+
+	name string
+	conn *ethclient.Client
+
+	// backend sends the transactions of the node, it is conn itself or a
+	// private backend if the node sends private transactions.
+	backend txSender
+
+	// private is nil if the node sends public transactions.
+	private *privateTxs
+
+	// senders are used in turn.
+	senders []*sender
+
+	// chainID is fetched once when the node is dialed, every transaction is
+	// signed with it.
+	chainID    *big.Int
+	txType     string
+	accessList types.AccessList
+	fees       *feeStrategy
+	gas        *gasPolicy
+	errPolicy  *errorPolicy
+
+	// contract is the contract workload of the test profile.
+	contract *Contract
+
+	// transfer is the value transfer workload of the test profile, it is
+	// nil if the test profile doesn't send transfers.
+	transfer *Transfer
+
+	results *logger.TestResults
+
+	// stats are the transaction counts of the test profile.
+	stats *profileStats
+
+	// receipts is nil if receipts are not tracked.
+	receipts *ReceiptTracker
+
+	// receiptsAbort is closed when the grace period of an interrupted test
+	// is over, the receipts are not waited for anymore.
+	receiptsAbort <-chan struct{}
+
+	// releaseSigner releases the connection to the external signer of the
+	// node.
+	releaseSigner func()
+	closeOnce     sync.Once
+}
+
+// newNodeConn dials the given node and prepares its sender accounts.
+func (d *DeployClient) newNodeConn(testProfile *config.TestProfile, nodeConfig *config.NodeConfig) (*nodeConn, error) {
+	rpcClient, err := d.createConn(nodeConfig.URL)
+	if err != nil {
+		return nil, fmt.Errorf("Error while creating ETH Client Connection: %v", err)
+	}
+	conn := ethclient.NewClient(rpcClient)
+
+	txType, err := parseTxType(testProfile.TxType)
+	if err != nil {
+		return nil, err
+	}
+	accessList, err := parseAccessList(testProfile.AccessList)
+	if err != nil {
+		return nil, err
+	}
+
+	var backend txSender = conn
+	var private *privateTxs
+	if nodeConfig.Private != nil {
+		if txType != TxTypeLegacy {
+			return nil, fmt.Errorf("private transactions can't be %s transactions", txType)
+		}
+		if nodeConfig.Signer != nil {
+			return nil, errors.New("private transactions can't be signed by an external signer")
+		}
+		private, err = newPrivateTxs(nodeConfig.Private)
+		if err != nil {
+			return nil, fmt.Errorf("Error while parsing private transaction config: %v", err)
+		}
+		backend = private.backend(rpcClient)
+	}
+	if testProfile.Batch != nil {
+		method, extraArgs := "eth_sendRawTransaction", []interface{}(nil)
+		if private != nil {
+			method = "eth_sendRawPrivateTransaction"
+			extraArgs = []interface{}{sendRawPrivateTxArgs{PrivateFor: private.privateFor}}
+		}
+		backend, err = newBatchBackend(rpcClient, testProfile.Batch, d.Logger.TestResult, method, extraArgs...)
+		if err != nil {
+			return nil, fmt.Errorf("Error while parsing batch config: %v", err)
+		}
+	}
+
+	chainID, err := conn.ChainID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("Error while fetching chain id: %v", err)
+	}
+
+	signers, releaseSigner, err := d.nodeSigners(nodeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error while loading sender accounts: %v", err)
+	}
+	// the signer connection is released if the node can't be prepared.
+	prepared := false
+	defer func() {
+		if !prepared {
+			releaseSigner()
+		}
+	}()
+
+	var senders []*sender
+	for _, signer := range signers {
+		address := signer.Address()
+		senders = append(senders, &sender{
+			signer:  signer,
+			address: address,
+			nonces:  d.getNonceManager(conn, address),
+		})
+	}
+	if len(senders) > 1 {
+		log.Infof("[%s] node has %d sender accounts.", nodeConfig.Name, len(senders))
+	}
+
+	refreshInterval := DefaultGasPriceRefreshInterval
+	if testProfile.GasPriceRefreshInterval != "" {
+		refreshInterval, err = util.ParseDuration(testProfile.GasPriceRefreshInterval)
+		if err != nil {
+			return nil, fmt.Errorf("Error while parsing gas price refresh interval: %v", err)
+		}
+	}
+
+	fees, err := newFeeStrategy(txType, testProfile.Fees, conn, refreshInterval)
+	if err != nil {
+		return nil, fmt.Errorf("Error while parsing fees: %v", err)
+	}
+
+	gas, err := newGasPolicy(testProfile.Gas, conn)
+	if err != nil {
+		return nil, fmt.Errorf("Error while parsing gas config: %v", err)
+	}
+
+	errPolicy, err := newErrorPolicy(testProfile.Errors)
+	if err != nil {
+		return nil, fmt.Errorf("Error while parsing errors config: %v", err)
+	}
+
+	usesTransfer := testProfile.Transfer != nil
+	usesContract := testProfile.Transfer == nil
+	if testProfile.Mix != nil {
+		// a mixed workload can use both of them.
+		usesTransfer = testProfile.Mix.Transfer > 0
+		usesContract = testProfile.Mix.Deploy > 0 || testProfile.Mix.Call > 0
+	}
+
+	var contract *Contract
+	var transfer *Transfer
+	if usesTransfer {
+		transferConfig := testProfile.Transfer
+		if transferConfig == nil {
+			transferConfig = &config.TransferConfig{}
+		}
+		transfer, err = LoadTransfer(transferConfig)
+		if err != nil {
+			return nil, fmt.Errorf("Error while parsing transfer config: %v", err)
+		}
+	}
+	if usesContract {
+		contract, err = d.getContract(testProfile)
+		if err != nil {
+			return nil, fmt.Errorf("Error while loading contract: %v", err)
+		}
+	}
+
+	stats := d.profileStats(testProfile)
+	receipts, err := receiptTrackerFromConfig(nodeConfig.Name, conn, d.Logger.TestResult, stats, testProfile.Receipts)
+	if err != nil {
+		return nil, fmt.Errorf("Error while parsing receipts config: %v", err)
+	}
+
+	prepared = true
+	return &nodeConn{
+		name:       nodeConfig.Name,
+		conn:       conn,
+		backend:    backend,
+		private:    private,
+		senders:    senders,
+		chainID:    chainID,
+		txType:     txType,
+		accessList: accessList,
+		fees:       fees,
+		gas:        gas,
+		errPolicy:  errPolicy,
+		contract:   contract,
+		transfer:   transfer,
+		results:    d.Logger.TestResult,
+		stats:      stats,
+		receipts:   receipts,
+
+		receiptsAbort: d.receiptsAbort,
+		releaseSigner: releaseSigner,
+	}, nil
+}
+
+// nodeSigners returns the signers of the sender accounts of the given node,
+// they are either local keys or accounts of the external signer of the node.
+// The returned function releases the connection to the external signer.
+func (d *DeployClient) nodeSigners(nodeConfig *config.NodeConfig) ([]Signer, func(), error) {
+	if nodeConfig.Signer != nil {
+		if nodeConfig.Cipher != "" || nodeConfig.Keystore != "" || nodeConfig.Senders != nil {
+			return nil, nil, errors.New("node with an external signer can't have a cipher, a keystore or senders")
+		}
+		return d.newClefSigners(nodeConfig.Signer)
+	}
+
+	privateKeys, err := d.getSenderKeys(nodeConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	var signers []Signer
+	for _, privateKey := range privateKeys {
+		signers = append(signers, NewLocalSigner(privateKey))
+	}
+	return signers, func() {}, nil
+}
+
+// getNonceManager returns the nonce manager of the given account. Nonce
+// managers are shared by every node and test profile that sends from the
+// same account.
+func (d *DeployClient) getNonceManager(conn *ethclient.Client, address common.Address) *NonceManager {
+	d.nonceMu.Lock()
+	defer d.nonceMu.Unlock()
+
+	nonces, ok := d.nonceManagers[address]
+	if !ok {
+		nonces = NewNonceManager(conn, address)
+		d.nonceManagers[address] = nonces
+	}
+	return nonces
+}
+
+// getContract returns the contract workload of the given test profile. It is
+// loaded once per test profile so the argument generators are shared by
+// every node of the profile.
+func (d *DeployClient) getContract(testProfile *config.TestProfile) (*Contract, error) {
+	d.contractMu.Lock()
+	defer d.contractMu.Unlock()
+
+	contract, ok := d.contracts[testProfile]
+	if !ok {
+		var err error
+		contract, err = LoadContract(testProfile.Contract)
+		if err != nil {
+			return nil, err
+		}
+		d.contracts[testProfile] = contract
+	}
+	return contract, nil
+}
+
+// nextSender returns the sender of the next transaction of the node,
+// rotating over the sender pool.
+func (n *nodeConn) nextSender() *sender {
+	index := atomic.AddUint64(&n.nextSenderIndex, 1) - 1
+	return n.senders[index%uint64(len(n.senders))]
+}
+
+// transactOpts returns the transaction options of the next transaction of
+// the given sender with a locally allocated nonce and the cached fees. The
+// fees and the nonce are fetched, and the transaction is signed, with ctx.
+func (n *nodeConn) transactOpts(ctx context.Context, from *sender) (*txOpts, error) {
+	fees, err := n.fees.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := from.nonces.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := &bind.TransactOpts{
+		From: from.address,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			return from.signer.SignTx(ctx, tx, n.chainID)
+		},
+		Context: ctx,
+	}
+	if n.private != nil {
+		// nodes with an external signer can't send private transactions,
+		// so the signer is local.
+		auth.Signer = n.private.signer(ctx, from.signer.(*LocalSigner))
+	}
+	auth.Nonce = new(big.Int).SetUint64(nonce)
+	auth.Value = big.NewInt(0)              // in wei
+	auth.GasLimit = uint64(DefaultGasLimit) // in units
+	auth.GasPrice = fees.GasPrice
+	auth.GasFeeCap = fees.GasFeeCap
+	auth.GasTipCap = fees.GasTipCap
+
+	return &txOpts{
+		TransactOpts: auth,
+		txType:       n.txType,
+		chainID:      n.chainID,
+		accessList:   n.accessList,
+		gas:          n.gas,
+	}, nil
+}
+
+// txSent counts the given transaction, records its send latency and adds it
+// to the receipt tracker of the node if receipts are tracked.
+func (n *nodeConn) txSent(tx *types.Transaction, submittedAt time.Time) {
+	n.results.AddTxCount(1)
+	n.stats.addSent()
+	if n.private != nil {
+		n.results.AddPrivateTxCount(1)
+	}
+	n.results.AddSendLatency(n.name, time.Since(submittedAt))
+	if n.receipts != nil {
+		n.receipts.Track(tx.Hash(), tx.Gas(), submittedAt)
+	}
+}
+
+// signAndSend signs a transaction of the given sender with sign and sends
+// it. The transaction is interrupted when ctx is done.
+func (n *nodeConn) signAndSend(ctx context.Context, from *sender, sign func(opts *txOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	opts, err := n.transactOpts(ctx, from)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, n.txInterrupted(nil, ctx.Err())
+		}
+		return nil, n.txFailed(nil, 0, err)
+	}
+	tx, err := sign(opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, n.txInterrupted(from, ctx.Err())
+		}
+		return nil, n.txFailed(from, opts.Nonce.Uint64(), err)
+	}
+	if err := n.sendTx(ctx, from, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// sendTx sends the given signed transaction of the given sender, it is
+// resent after a backoff if the policy of its error class is retry. from is
+// nil for pre-signed transactions, their nonces are fixed. The send and the
+// backoff are interrupted when ctx is done.
+func (n *nodeConn) sendTx(ctx context.Context, from *sender, tx *types.Transaction) error {
+	for retry := 0; ; retry++ {
+		submittedAt := time.Now()
+		err := n.backend.SendTransaction(ctx, tx)
+		// a resent transaction is known if an earlier attempt reached the
+		// node.
+		if err == nil || (retry > 0 && isKnownTxError(err)) {
+			n.txSent(tx, submittedAt)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return n.txInterrupted(from, ctx.Err())
+		}
+
+		delay, ok := n.errPolicy.retryDelay(classifyError(err), retry)
+		if !ok {
+			return n.txFailed(from, tx.Nonce(), err)
+		}
+		log.Warnf("[%s] Error while sending transaction, retrying in %s: %v", n.name, delay, err)
+		if !sleepContext(ctx, delay) {
+			return n.txInterrupted(from, ctx.Err())
+		}
+	}
+}
+
+// txInterrupted returns the error of a transaction whose sending is
+// interrupted because the test is stopped. It isn't counted as failed. The
+// nonce manager of the given sender is resynced, since the transaction may
+// have reached the node before it was interrupted.
+func (n *nodeConn) txInterrupted(from *sender, err error) error {
+	if from != nil {
+		from.nonces.Resync()
+	}
+	return err
+}
+
+// txFailed counts the error of a transaction that couldn't be sent and
+// applies the policy of its error class. If the node rejected the
+// transaction, the given nonce isn't used by the node, so it is given back
+// to the nonce manager of the given sender. The manager is resynced instead
+// by the resync policy, by the retry policy when every retry failed since an
+// earlier attempt may have reached the node, and for timeouts and connection
+// resets whatever the policy, since the transaction may be in the pool of
+// the node. from is nil if no nonce was allocated or the nonce is fixed. The
+// abort policy returns an abortError that fails the node.
+func (n *nodeConn) txFailed(from *sender, nonce uint64, err error) error {
+	class := classifyError(err)
+	n.results.AddError(class)
+	n.stats.addFailed()
+	if isOutOfGasError(err) {
+		n.results.AddOutOfGasTx()
+	}
+	log.Errorf("[%s] Error while sending transaction (%s): %v", n.name, class, err)
+
+	policy := n.errPolicy.policy(class)
+	if from != nil {
+		if policy == ErrPolicyResync || policy == ErrPolicyRetry || mayHaveReachedNode(class) {
+			log.Warnf("[%s] Resyncing nonce of %s.", n.name, from.address.Hex())
+			from.nonces.Resync()
+		} else {
+			from.nonces.Release(nonce)
+		}
+	}
+
+	if policy == ErrPolicyAbort {
+		return &abortError{class: class, err: err}
+	}
+	return err
+}
+
+// waitReceipts waits for the receipts of the tracked transactions of the
+// node, or until the grace period of an interrupted test is over.
+func (n *nodeConn) waitReceipts() {
+	if n.receipts != nil {
+		n.receipts.Wait(n.receiptsAbort)
+	}
+}
+
+// close is called when the load of the node is finished, it waits for the
+// receipts of the node and releases its connection to the external signer.
+// Only the first call has an effect.
+func (n *nodeConn) close() {
+	n.closeOnce.Do(func() {
+		n.waitReceipts()
+		if n.releaseSigner != nil {
+			n.releaseSigner()
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultGasPriceRefreshInterval is used when the test profile doesn't have a
+// gas price refresh interval.
+const DefaultGasPriceRefreshInterval = 10 * time.Second
+
+// nonceSource returns the pending nonce of an account, ethclient.Client
+// satisfies it.
+type nonceSource interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// gasPriceSource returns the suggested gas price of a node, ethclient.Client
+// satisfies it.
+type gasPriceSource interface {
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+}
+
+// NonceManager hands out the nonces of an account locally. The pending nonce
+// is fetched from the node only for the first transaction and after a nonce
+// error, so transactions can be sent concurrently from the same account.
+type NonceManager struct {
+	source  nonceSource
+	address common.Address
+
+	mu     sync.Mutex
+	nonce  uint64
+	synced bool
+}
+
+func NewNonceManager(source nonceSource, address common.Address) *NonceManager {
+	return &NonceManager{
+		source:  source,
+		address: address,
+	}
+}
+
+// Next returns the next nonce of the account, fetching the pending nonce
+// from the node if the manager is not synced.
+func (n *NonceManager) Next(ctx context.Context) (uint64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.synced {
+		nonce, err := n.source.PendingNonceAt(ctx, n.address)
+		if err != nil {
+			return 0, err
+		}
+		n.nonce = nonce
+		n.synced = true
+	}
+
+	nonce := n.nonce
+	n.nonce++
+	return nonce, nil
+}
+
+// Resync makes the manager fetch the pending nonce from the node again
+// before handing out the next nonce.
+func (n *NonceManager) Resync() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.synced = false
+}
+
+// Release gives back the given nonce of a transaction that wasn't accepted
+// by the node, so no later transaction of the account waits for it forever.
+// If it is the last handed out nonce, it is handed out again, otherwise the
+// later nonces are already in use and the manager is resynced.
+func (n *NonceManager) Release(nonce uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.synced && nonce+1 == n.nonce {
+		n.nonce = nonce
+		return
+	}
+	n.synced = false
+}
+
+// bigIntCache caches a value fetched from a node and refreshes it when it
+// is older than the refresh interval.
+type bigIntCache struct {
+	fetch    func(ctx context.Context) (*big.Int, error)
+	interval time.Duration
+
+	mu        sync.Mutex
+	value     *big.Int
+	updatedAt time.Time
+}
+
+// get returns the cached value, refreshing it if it is expired.
+func (c *bigIntCache) get(ctx context.Context) (*big.Int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.value == nil || time.Since(c.updatedAt) >= c.interval {
+		value, err := c.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.value = value
+		c.updatedAt = time.Now()
+	}
+
+	return new(big.Int).Set(c.value), nil
+}
+
+// GasPriceCache caches the suggested gas price of a node and refreshes it
+// from the node when it is older than the refresh interval.
+type GasPriceCache struct {
+	cache bigIntCache
+}
+
+func NewGasPriceCache(source gasPriceSource, interval time.Duration) *GasPriceCache {
+	return &GasPriceCache{
+		cache: bigIntCache{
+			fetch:    source.SuggestGasPrice,
+			interval: interval,
+		},
+	}
+}
+
+// Get returns the cached gas price, refreshing it if it is expired.
+func (g *GasPriceCache) Get(ctx context.Context) (*big.Int, error) {
+	return g.cache.get(ctx)
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type fakeNode struct {
+	mu           sync.Mutex
+	pendingNonce uint64
+	nonceCalls   int
+	gasCalls     int
+
+	tipCap  *big.Int
+	baseFee *big.Int
+}
+
+func (f *fakeNode) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nonceCalls++
+	return f.pendingNonce, nil
+}
+
+func (f *fakeNode) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gasCalls++
+	return big.NewInt(int64(f.gasCalls)), nil
+}
+
+func (f *fakeNode) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return new(big.Int).Set(f.tipCap), nil
+}
+
+func (f *fakeNode) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &types.Header{BaseFee: f.baseFee}, nil
+}
+
+func TestNonceManagerConcurrentNext(t *testing.T) {
+	node := &fakeNode{pendingNonce: 5}
+	nonces := NewNonceManager(node, common.Address{})
+
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := nonces.Next(context.Background())
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			mu.Lock()
+			seen[nonce] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for nonce := uint64(5); nonce < 55; nonce++ {
+		if !seen[nonce] {
+			t.Errorf("nonce %d is not handed out", nonce)
+		}
+	}
+	if node.nonceCalls != 1 {
+		t.Errorf("pending nonce fetched %d times, want 1", node.nonceCalls)
+	}
+}
+
+func TestNonceManagerResync(t *testing.T) {
+	node := &fakeNode{pendingNonce: 3}
+	nonces := NewNonceManager(node, common.Address{})
+
+	nonces.Next(context.Background())
+	nonces.Next(context.Background())
+
+	nonces.Resync()
+	node.pendingNonce = 10
+	nonce, _ := nonces.Next(context.Background())
+	if nonce != 10 {
+		t.Errorf("nonce after resync = %d, want 10", nonce)
+	}
+}
+
+func TestNonceManagerRelease(t *testing.T) {
+	node := &fakeNode{pendingNonce: 3}
+	nonces := NewNonceManager(node, common.Address{})
+
+	nonces.Next(context.Background())
+	last, _ := nonces.Next(context.Background())
+
+	// the last nonce is handed out again.
+	nonces.Release(last)
+	if nonce, _ := nonces.Next(context.Background()); nonce != last {
+		t.Errorf("nonce after release = %d, want %d", nonce, last)
+	}
+
+	// an earlier nonce leaves a gap, so the manager is resynced.
+	nonces.Release(3)
+	node.pendingNonce = 3
+	if nonce, _ := nonces.Next(context.Background()); nonce != 3 {
+		t.Errorf("nonce after releasing an earlier nonce = %d, want 3", nonce)
+	}
+}
+
+func TestGasPriceCache(t *testing.T) {
+	node := &fakeNode{}
+	gasPrices := NewGasPriceCache(node, 20*time.Millisecond)
+
+	first, _ := gasPrices.Get(context.Background())
+	second, _ := gasPrices.Get(context.Background())
+	if first.Cmp(second) != 0 || node.gasCalls != 1 {
+		t.Errorf("gas price is not cached, fetched %d times", node.gasCalls)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	third, _ := gasPrices.Get(context.Background())
+	if third.Cmp(first) == 0 || node.gasCalls != 2 {
+		t.Errorf("gas price is not refreshed, fetched %d times", node.gasCalls)
+	}
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/util"
+)
+
+// Phase types of a test profile load shape.
+const (
+	PhaseRamp  = "ramp"
+	PhaseHold  = "hold"
+	PhaseStep  = "step"
+	PhaseSpike = "spike"
+	PhaseSoak  = "soak"
+)
+
+// loadShape is the parsed form of a config.Phase.
+type loadShape struct {
+	name        string
+	description string
+	duration    time.Duration
+	rateFunc    RateFunc
+}
+
+// newLoadShape parses the given phase and returns its load shape.
+func newLoadShape(phase *config.Phase, index int) (*loadShape, error) {
+	shape := &loadShape{name: phase.Name}
+	if shape.name == "" {
+		shape.name = fmt.Sprintf("Phase %d", index+1)
+	}
+
+	var err error
+	shape.duration, err = util.ParseDuration(phase.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] invalid duration: %v", shape.name, err)
+	}
+	if shape.duration <= 0 {
+		return nil, fmt.Errorf("[%s] duration must be positive", shape.name)
+	}
+
+	switch phase.Type {
+	case PhaseRamp:
+		from, err := parsePhaseRate(phase.From, true)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] invalid from rate: %v", shape.name, err)
+		}
+		to, err := parsePhaseRate(phase.To, true)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] invalid to rate: %v", shape.name, err)
+		}
+
+		duration := shape.duration
+		shape.rateFunc = func(elapsed time.Duration) float64 {
+			return from + (to-from)*elapsed.Seconds()/duration.Seconds()
+		}
+		shape.description = fmt.Sprintf("%.2f -> %.2f tx/s", from, to)
+
+	case PhaseHold, PhaseSoak:
+		rate, err := parsePhaseRate(phase.Rate, false)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] invalid rate: %v", shape.name, err)
+		}
+
+		shape.rateFunc = ConstantRate(rate)
+		shape.description = fmt.Sprintf("%.2f tx/s", rate)
+
+	case PhaseStep:
+		from, err := parsePhaseRate(phase.From, true)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] invalid from rate: %v", shape.name, err)
+		}
+		step, err := parsePhaseRate(phase.Step, false)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] invalid step rate: %v", shape.name, err)
+		}
+		stepDuration, err := util.ParseDuration(phase.StepDuration)
+		if err != nil || stepDuration <= 0 {
+			return nil, fmt.Errorf("[%s] invalid step duration: %q", shape.name, phase.StepDuration)
+		}
+		to := math.Inf(1)
+		if phase.To != "" {
+			to, err = parsePhaseRate(phase.To, false)
+			if err != nil {
+				return nil, fmt.Errorf("[%s] invalid to rate: %v", shape.name, err)
+			}
+		}
+
+		shape.rateFunc = func(elapsed time.Duration) float64 {
+			steps := math.Floor(float64(elapsed) / float64(stepDuration))
+			return math.Min(from+steps*step, to)
+		}
+		shape.description = fmt.Sprintf("%.2f tx/s + %.2f tx/s every %s", from, step, stepDuration)
+
+	case PhaseSpike:
+		rate, err := parsePhaseRate(phase.Rate, true)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] invalid rate: %v", shape.name, err)
+		}
+		spikeRate, err := parsePhaseRate(phase.SpikeRate, false)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] invalid spike rate: %v", shape.name, err)
+		}
+		spikeDuration, err := util.ParseDuration(phase.SpikeDuration)
+		if err != nil || spikeDuration <= 0 {
+			return nil, fmt.Errorf("[%s] invalid spike duration: %q", shape.name, phase.SpikeDuration)
+		}
+		spikeInterval := shape.duration
+		if phase.SpikeInterval != "" {
+			spikeInterval, err = util.ParseDuration(phase.SpikeInterval)
+			if err != nil || spikeInterval <= 0 {
+				return nil, fmt.Errorf("[%s] invalid spike interval: %q", shape.name, phase.SpikeInterval)
+			}
+		}
+
+		shape.rateFunc = func(elapsed time.Duration) float64 {
+			if elapsed%spikeInterval < spikeDuration {
+				return spikeRate
+			}
+			return rate
+		}
+		shape.description = fmt.Sprintf("%.2f tx/s with %.2f tx/s spikes of %s", rate, spikeRate, spikeDuration)
+
+	default:
+		return nil, fmt.Errorf("[%s] unknown phase type: %q", shape.name, phase.Type)
+	}
+
+	return shape, nil
+}
+
+// parsePhaseRate parses the given rate string, if allowZero is true an empty
+// string or "0" is accepted as zero rate.
+func parsePhaseRate(rateStr string, allowZero bool) (float64, error) {
+	if allowZero && (rateStr == "" || rateStr == "0") {
+		return 0, nil
+	}
+	return util.ParseRate(rateStr)
+}
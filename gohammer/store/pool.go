@@ -0,0 +1,41 @@
+package store
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Workiva/go-datastructures/queue"
+)
+
+// queuePollTimeout is how long a worker waits for a new item before it
+// decides that the queue is drained.
+const queuePollTimeout = 10 * time.Millisecond
+
+// runWorkerPool puts the given items to a queue and calls fn for every item
+// with at most workers goroutines running at the same time.
+// It returns when every item is processed.
+func runWorkerPool(workers int, items []interface{}, fn func(item interface{})) {
+	if workers <= 0 || workers > len(items) {
+		workers = len(items)
+	}
+
+	deployQueue := queue.New(int64(len(items)))
+	deployQueue.Put(items...)
+	defer deployQueue.Dispose()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				item, err := deployQueue.Poll(1, queuePollTimeout)
+				if err != nil || len(item) == 0 {
+					return
+				}
+				fn(item[0])
+			}
+		}()
+	}
+	wg.Wait()
+}
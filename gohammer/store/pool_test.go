@@ -0,0 +1,41 @@
+package store
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunWorkerPool(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	processed := make(map[int]bool)
+
+	var items []interface{}
+	for i := 0; i < 10; i++ {
+		items = append(items, i)
+	}
+
+	runWorkerPool(3, items, func(item interface{}) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		processed[item.(int)] = true
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+
+	if len(processed) != len(items) {
+		t.Errorf("processed %d items, want %d", len(processed), len(items))
+	}
+	if maxRunning > 3 {
+		t.Errorf("%d workers ran at the same time, want at most 3", maxRunning)
+	}
+}
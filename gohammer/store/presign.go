@@ -0,0 +1,263 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+var errPresignedTxsExhausted = errors.New("every pre-signed transaction is sent")
+
+// presignedNode is a node with the transactions that are signed for it
+// before the test.
+type presignedNode struct {
+	// next is the index of the next transaction, it is updated atomically
+	// so it is kept as the first field for 64-bit alignment.
+	next uint64
+
+	node *nodeConn
+	txs  []*types.Transaction
+
+	exhaustedOnce sync.Once
+}
+
+// send sends the next pre-signed transaction of the node.
+func (p *presignedNode) send(ctx context.Context) error {
+	index := atomic.AddUint64(&p.next, 1) - 1
+	if index >= uint64(len(p.txs)) {
+		return errPresignedTxsExhausted
+	}
+	return p.node.sendTx(ctx, nil, p.txs[index])
+}
+
+// sendAndLog sends the next pre-signed transaction of the node, running out
+// of transactions is logged only once and it is not returned as an error.
+// Send errors are logged by sendTx.
+func (p *presignedNode) sendAndLog(ctx context.Context) error {
+	err := p.send(ctx)
+	if err == errPresignedTxsExhausted {
+		p.exhaustedOnce.Do(func() {
+			log.Warnf("[%s] All %d pre-signed transactions are sent, increase the pre-sign count.", p.node.name, len(p.txs))
+		})
+		return nil
+	}
+	return err
+}
+
+// presignTestProfile connects to the nodes of the test profile and signs
+// their transactions, or reads them from the pre-sign input file. The
+// returned nodes are in the order of the test profile nodes.
+func (d *DeployClient) presignTestProfile(ctx context.Context, testProfile *config.TestProfile) ([]*presignedNode, error) {
+	preSign := testProfile.PreSign
+
+	var loaded map[string][]*types.Transaction
+	if preSign.Input != "" {
+		var err error
+		loaded, err = readPresignedTxs(preSign.Input)
+		if err != nil {
+			return nil, fmt.Errorf("Error while reading pre-signed transactions: %v", err)
+		}
+	}
+
+	// the nodes are closed if the test profile can't be pre-signed.
+	var nodes []*nodeConn
+	prepared := false
+	defer func() {
+		if !prepared {
+			for _, node := range nodes {
+				node.close()
+			}
+		}
+	}()
+
+	var presigned []*presignedNode
+	for i := range testProfile.Nodes {
+		nodeConfig := &testProfile.Nodes[i]
+		node, err := d.newNodeConn(testProfile, nodeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("Error while connecting to [%s] node: %v", nodeConfig.Name, err)
+		}
+		nodes = append(nodes, node)
+
+		var txs []*types.Transaction
+		if loaded != nil {
+			txs = loaded[nodeConfig.Name]
+			if len(txs) == 0 {
+				return nil, fmt.Errorf("%s has no transactions of [%s] node", preSign.Input, nodeConfig.Name)
+			}
+			log.Infof("[%s] Loaded %d pre-signed transactions.", nodeConfig.Name, len(txs))
+		} else {
+			count, err := presignCount(testProfile, nodeConfig, len(node.senders))
+			if err != nil {
+				return nil, err
+			}
+
+			signStart := time.Now()
+			txs, err = d.signNodeTxs(ctx, testProfile, node, count)
+			if err != nil {
+				return nil, fmt.Errorf("Error while signing transactions of [%s] node: %v", nodeConfig.Name, err)
+			}
+			log.Infof("[%s] Signed %d transactions in %s.", nodeConfig.Name, len(txs), time.Since(signStart))
+		}
+
+		presigned = append(presigned, &presignedNode{node: node, txs: txs})
+	}
+
+	if preSign.Output != "" {
+		if err := writePresignedTxs(preSign.Output, presigned); err != nil {
+			return nil, fmt.Errorf("Error while writing pre-signed transactions: %v", err)
+		}
+	}
+	prepared = true
+	return presigned, nil
+}
+
+// presignCount returns the number of transactions signed for every sender
+// of the given node.
+func presignCount(testProfile *config.TestProfile, nodeConfig *config.NodeConfig, senderCount int) (int, error) {
+	if testProfile.PreSign.Count > 0 {
+		return testProfile.PreSign.Count, nil
+	}
+	if len(testProfile.Phases) > 0 {
+		return 0, errors.New("pre-sign count is required if the test profile has phases")
+	}
+
+	// round robin profiles send the sum of the deploy counts of their nodes
+	// through the dispatcher. Any node may be sent all of them, e.g. by the
+	// weighted, leastOutstanding and random strategies or when the other
+	// nodes fail, so every node is signed the whole sum.
+	deployCounts := nodeConfig.DeployCounts
+	if testProfile.RoundRobin {
+		var nodes []*config.NodeConfig
+		for i := range testProfile.Nodes {
+			nodes = append(nodes, &testProfile.Nodes[i])
+		}
+		deployCounts = rrDeployCounts(nodes)
+	}
+
+	total := 0
+	for _, deployCount := range deployCounts {
+		total += deployCount
+	}
+	return (total + senderCount - 1) / senderCount, nil
+}
+
+// signNodeTxs signs count transactions of the test profile workload for
+// every sender of the node. The transactions of the senders are
+// interleaved, so they are sent in turn like the transactions of a running
+// test.
+func (d *DeployClient) signNodeTxs(ctx context.Context, testProfile *config.TestProfile, node *nodeConn, count int) ([]*types.Transaction, error) {
+	var sign func(opts *txOpts) (*types.Transaction, error)
+	switch {
+	case node.transfer != nil:
+		sign = func(opts *txOpts) (*types.Transaction, error) {
+			return node.transfer.Sign(opts, node.transfer.recipient(node.senders))
+		}
+	case testProfile.CallContractMethod:
+		address, err := d.getContractInstance(ctx, node)
+		if err != nil {
+			return nil, fmt.Errorf("Error while creating %s Instance: %v", node.contract.Name, err)
+		}
+		sign = func(opts *txOpts) (*types.Transaction, error) {
+			return node.contract.SignCall(opts, address, node.name)
+		}
+	default:
+		sign = func(opts *txOpts) (*types.Transaction, error) {
+			return node.contract.SignDeploy(opts, node.name)
+		}
+	}
+
+	senderTxs := make([][]*types.Transaction, len(node.senders))
+	for i, from := range node.senders {
+		for j := 0; j < count; j++ {
+			opts, err := node.transactOpts(ctx, from)
+			if err != nil {
+				return nil, err
+			}
+			tx, err := sign(opts)
+			if err != nil {
+				return nil, err
+			}
+			senderTxs[i] = append(senderTxs[i], tx)
+		}
+	}
+
+	var txs []*types.Transaction
+	for j := 0; j < count; j++ {
+		for i := range senderTxs {
+			txs = append(txs, senderTxs[i][j])
+		}
+	}
+	return txs, nil
+}
+
+// presignedTxEntry is a line of a pre-signed transactions file.
+type presignedTxEntry struct {
+	Node string        `json:"node"`
+	Tx   hexutil.Bytes `json:"tx"`
+}
+
+// writePresignedTxs writes the transactions of the given nodes to a file,
+// one JSON object with the node name and the raw transaction per line.
+func writePresignedTxs(path string, presigned []*presignedNode) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, p := range presigned {
+		for _, tx := range p.txs {
+			raw, err := tx.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			if err := encoder.Encode(presignedTxEntry{Node: p.node.name, Tx: raw}); err != nil {
+				return err
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// readPresignedTxs reads a file written by writePresignedTxs and returns the
+// transactions of every node in their original order.
+func readPresignedTxs(path string) (map[string][]*types.Transaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	txs := make(map[string][]*types.Transaction)
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for decoder.More() {
+		var entry presignedTxEntry
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, err
+		}
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(entry.Tx); err != nil {
+			return nil, fmt.Errorf("invalid transaction of [%s] node: %v", entry.Node, err)
+		}
+		txs[entry.Node] = append(txs[entry.Node], tx)
+	}
+	return txs, nil
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+func newTestNodeConn(t *testing.T, name string, senderCount int) *nodeConn {
+	source := &fakeNode{pendingNonce: 10}
+	fees, err := newFeeStrategy(TxTypeLegacy, nil, source, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gas, err := newGasPolicy(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errPolicy, err := newErrorPolicy(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := &nodeConn{
+		name:      name,
+		chainID:   big.NewInt(1337),
+		txType:    TxTypeLegacy,
+		fees:      fees,
+		gas:       gas,
+		errPolicy: errPolicy,
+	}
+	for i := 0; i < senderCount; i++ {
+		privateKey, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer := NewLocalSigner(privateKey)
+		node.senders = append(node.senders, &sender{
+			signer:  signer,
+			address: signer.Address(),
+			nonces:  NewNonceManager(source, signer.Address()),
+		})
+	}
+	return node
+}
+
+func TestSignNodeTxs(t *testing.T) {
+	node := newTestNodeConn(t, "node1", 2)
+	node.transfer, _ = LoadTransfer(&config.TransferConfig{})
+
+	d := &DeployClient{}
+	txs, err := d.signNodeTxs(context.Background(), &config.TestProfile{}, node, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 6 {
+		t.Fatalf("signed %d transactions, want 6", len(txs))
+	}
+
+	// senders are interleaved and every sender has consecutive nonces.
+	for i, tx := range txs {
+		if want := uint64(10 + i/2); tx.Nonce() != want {
+			t.Errorf("transaction %d has nonce %d, want %d", i, tx.Nonce(), want)
+		}
+		if to := *tx.To(); to != node.senders[0].address && to != node.senders[1].address {
+			t.Errorf("transaction %d has unexpected recipient %s", i, tx.To().Hex())
+		}
+	}
+}
+
+func TestPresignedTxsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "presign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := &DeployClient{}
+	var presigned []*presignedNode
+	for _, name := range []string{"node1", "node2"} {
+		node := newTestNodeConn(t, name, 1)
+		node.transfer, _ = LoadTransfer(&config.TransferConfig{})
+		txs, err := d.signNodeTxs(context.Background(), &config.TestProfile{}, node, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		presigned = append(presigned, &presignedNode{node: node, txs: txs})
+	}
+
+	path := filepath.Join(dir, "txs.jsonl")
+	if err := writePresignedTxs(path, presigned); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := readPresignedTxs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range presigned {
+		txs := loaded[p.node.name]
+		if len(txs) != len(p.txs) {
+			t.Fatalf("[%s] loaded %d transactions, want %d", p.node.name, len(txs), len(p.txs))
+		}
+		for i := range txs {
+			if txs[i].Hash() != p.txs[i].Hash() {
+				t.Errorf("[%s] transaction %d is %s, want %s", p.node.name, i, txs[i].Hash().Hex(), p.txs[i].Hash().Hex())
+			}
+		}
+	}
+}
+
+func TestPresignCount(t *testing.T) {
+	testProfile := &config.TestProfile{
+		Nodes: []config.NodeConfig{
+			{Name: "node1", DeployCounts: []int{10, 5}, Weight: 3},
+			{Name: "node2", DeployCounts: []int{1}, Weight: 1},
+		},
+		PreSign: &config.PreSignConfig{},
+	}
+
+	tests := []struct {
+		roundRobin  bool
+		dispatch    string
+		node        int
+		senderCount int
+		want        int
+	}{
+		{false, "", 0, 1, 15},
+		{false, "", 0, 4, 4},
+		{false, "", 1, 1, 1},
+		// every node of a round robin profile is signed all of its
+		// transactions, whatever the weights.
+		{true, "", 1, 1, 16},
+		{true, DispatchWeighted, 0, 1, 16},
+		{true, DispatchWeighted, 1, 1, 16},
+		{true, DispatchWeighted, 1, 4, 4},
+	}
+	for _, test := range tests {
+		testProfile.RoundRobin = test.roundRobin
+		testProfile.Dispatch = test.dispatch
+		count, err := presignCount(testProfile, &testProfile.Nodes[test.node], test.senderCount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != test.want {
+			t.Errorf("presignCount(roundRobin: %v, node: %d, senders: %d) = %d, want %d",
+				test.roundRobin, test.node, test.senderCount, count, test.want)
+		}
+	}
+
+	testProfile.Phases = []config.Phase{{Type: PhaseHold}}
+	if _, err := presignCount(testProfile, &testProfile.Nodes[0], 1); err == nil {
+		t.Error("presignCount didn't fail without a count for phases")
+	}
+}
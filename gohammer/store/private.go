@@ -0,0 +1,165 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+const privacyManagerTimeout = 30 * time.Second
+
+// PrivacyManager is a client of the third party API of a Quorum privacy
+// manager like Tessera.
+type PrivacyManager struct {
+	url    string
+	client *http.Client
+}
+
+func NewPrivacyManager(url string) *PrivacyManager {
+	return &PrivacyManager{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: privacyManagerTimeout},
+	}
+}
+
+type storeRawRequest struct {
+	Payload []byte `json:"payload"`
+	From    string `json:"from,omitempty"`
+}
+
+type storeRawResponse struct {
+	Key []byte `json:"key"`
+}
+
+// StoreRaw stores the given transaction payload in the privacy manager and
+// returns its hash. privateFrom is the sender enclave key, the default key of
+// the privacy manager is used if it is empty.
+func (p *PrivacyManager) StoreRaw(ctx context.Context, payload []byte, privateFrom string) ([]byte, error) {
+	body, err := json.Marshal(storeRawRequest{Payload: payload, From: privateFrom})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.url+"/storeraw", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error while storing private payload: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("privacy manager returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var result storeRawResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("Error while decoding privacy manager response: %v", err)
+	}
+	if len(result.Key) == 0 {
+		return nil, errors.New("privacy manager returned an empty payload hash")
+	}
+	return result.Key, nil
+}
+
+// privateTxSigner signs Quorum private transactions. They are signed like
+// homestead transactions, but the V value of the signature is 37 or 38
+// instead of 27 or 28, so nodes know the data is a privacy manager hash.
+type privateTxSigner struct {
+	types.HomesteadSigner
+}
+
+func (s privateTxSigner) SignatureValues(tx *types.Transaction, sig []byte) (r, sv, v *big.Int, err error) {
+	r, sv, v, err = s.HomesteadSigner.SignatureValues(tx, sig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return r, sv, v.Add(v, big.NewInt(10)), nil
+}
+
+// privateTxs makes the transactions of a node private transactions.
+type privateTxs struct {
+	manager     *PrivacyManager
+	privateFrom string
+	privateFor  []string
+}
+
+func newPrivateTxs(privateConfig *config.PrivateConfig) (*privateTxs, error) {
+	if privateConfig.PrivacyManagerURL == "" {
+		return nil, errors.New("privacy manager url is required")
+	}
+	if len(privateConfig.PrivateFor) == 0 {
+		return nil, errors.New("privateFor is required")
+	}
+	return &privateTxs{
+		manager:     NewPrivacyManager(privateConfig.PrivacyManagerURL),
+		privateFrom: privateConfig.PrivateFrom,
+		privateFor:  privateConfig.PrivateFor,
+	}, nil
+}
+
+// signer returns a bind.SignerFn that stores the payload of the given
+// transaction in the privacy manager and signs a private transaction with
+// its hash as data. The payload is stored with the given context.
+func (p *privateTxs) signer(ctx context.Context, local *LocalSigner) bind.SignerFn {
+	return func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		hash, err := p.manager.StoreRaw(ctx, tx.Data(), p.privateFrom)
+		if err != nil {
+			return nil, err
+		}
+
+		var privateTx *types.Transaction
+		if tx.To() == nil {
+			privateTx = types.NewContractCreation(tx.Nonce(), tx.Value(), tx.Gas(), tx.GasPrice(), hash)
+		} else {
+			privateTx = types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), tx.GasPrice(), hash)
+		}
+		return types.SignTx(privateTx, privateTxSigner{}, local.privateKey)
+	}
+}
+
+// backend returns a backend that sends the signed private transactions of
+// the node to their recipients.
+func (p *privateTxs) backend(rpcClient *rpc.Client) txSender {
+	return &privateBackend{rpcClient: rpcClient, privateFor: p.privateFor}
+}
+
+// privateBackend sends transactions with eth_sendRawPrivateTransaction.
+type privateBackend struct {
+	rpcClient  *rpc.Client
+	privateFor []string
+}
+
+type sendRawPrivateTxArgs struct {
+	PrivateFor []string `json:"privateFor"`
+}
+
+func (b *privateBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return b.rpcClient.CallContext(ctx, nil, "eth_sendRawPrivateTransaction",
+		hexutil.Encode(data), sendRawPrivateTxArgs{PrivateFor: b.privateFor})
+}
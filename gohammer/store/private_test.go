@@ -0,0 +1,170 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+// newStubPrivacyManager returns a privacy manager server that stores the
+// payloads it receives and returns a fixed hash.
+func newStubPrivacyManager(hash []byte, payloads *[]storeRawRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/storeraw" {
+			http.NotFound(w, r)
+			return
+		}
+		var req storeRawRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		*payloads = append(*payloads, req)
+		json.NewEncoder(w).Encode(storeRawResponse{Key: hash})
+	}))
+}
+
+func TestPrivateTxSigner(t *testing.T) {
+	hash := bytes.Repeat([]byte{0xab}, 64)
+	var payloads []storeRawRequest
+	server := newStubPrivacyManager(hash, &payloads)
+	defer server.Close()
+
+	private, err := newPrivateTxs(&config.PrivateConfig{
+		PrivacyManagerURL: server.URL,
+		PrivateFrom:       "BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo=",
+		PrivateFor:        []string{"QfeDAys9MPDs2XHExtc84jKGHxZg/aj52DTh0vtA3Xc="},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	payload := []byte{1, 2, 3}
+
+	tx, err := private.signer(context.Background(), NewLocalSigner(privateKey))(from, types.NewTransaction(3, to, big.NewInt(0), 300000, big.NewInt(0), payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(payloads) != 1 || !bytes.Equal(payloads[0].Payload, payload) || payloads[0].From != private.privateFrom {
+		t.Fatalf("unexpected stored payloads: %+v", payloads)
+	}
+	if !bytes.Equal(tx.Data(), hash) || tx.Nonce() != 3 || *tx.To() != to {
+		t.Errorf("unexpected private transaction: data %x, nonce %d, to %s", tx.Data(), tx.Nonce(), tx.To().Hex())
+	}
+
+	v, r, s := tx.RawSignatureValues()
+	if v.Uint64() != 37 && v.Uint64() != 38 {
+		t.Fatalf("private transaction has V %d, want 37 or 38", v)
+	}
+	sig := make([]byte, 65)
+	copy(sig[32-len(r.Bytes()):32], r.Bytes())
+	copy(sig[64-len(s.Bytes()):64], s.Bytes())
+	sig[64] = byte(v.Uint64() - 37)
+	publicKey, err := crypto.SigToPub(types.HomesteadSigner{}.Hash(tx).Bytes(), sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer := crypto.PubkeyToAddress(*publicKey); signer != from {
+		t.Errorf("private transaction is signed by %s, want %s", signer.Hex(), from.Hex())
+	}
+}
+
+func TestPrivacyManagerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown sender key", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := NewPrivacyManager(server.URL).StoreRaw(context.Background(), []byte{1}, ""); err == nil {
+		t.Error("StoreRaw didn't fail")
+	}
+}
+
+func TestPrivateBackendSendTransaction(t *testing.T) {
+	type rpcRequest struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+
+	var requests []rpcRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, req)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": common.Hash{}})
+	}))
+	defer server.Close()
+
+	rpcClient, err := rpc.DialHTTP(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcClient.Close()
+
+	privateFor := []string{"QfeDAys9MPDs2XHExtc84jKGHxZg/aj52DTh0vtA3Xc="}
+	backend := &privateBackend{rpcClient: rpcClient, privateFor: privateFor}
+
+	privateKey, _ := crypto.GenerateKey()
+	tx, err := types.SignTx(types.NewContractCreation(0, big.NewInt(0), 300000, big.NewInt(0), []byte{1}), privateTxSigner{}, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 1 || requests[0].Method != "eth_sendRawPrivateTransaction" || len(requests[0].Params) != 2 {
+		t.Fatalf("unexpected requests: %+v", requests)
+	}
+	var raw hexutil.Bytes
+	if err := json.Unmarshal(requests[0].Params[0], &raw); err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := tx.MarshalBinary(); !bytes.Equal(raw, want) {
+		t.Errorf("sent raw transaction %x, want %x", raw, want)
+	}
+	var args sendRawPrivateTxArgs
+	if err := json.Unmarshal(requests[0].Params[1], &args); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(args.PrivateFor, privateFor) {
+		t.Errorf("sent privateFor %v, want %v", args.PrivateFor, privateFor)
+	}
+}
+
+func TestNewPrivateTxsValidation(t *testing.T) {
+	invalid := []*config.PrivateConfig{
+		{PrivateFor: []string{"key"}},
+		{PrivacyManagerURL: "http://localhost:9081"},
+	}
+	for _, privateConfig := range invalid {
+		if _, err := newPrivateTxs(privateConfig); err == nil {
+			t.Errorf("newPrivateTxs(%+v) didn't fail", privateConfig)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/logger"
+	"github.com/tubuarge/GoHammer/util"
+)
+
+// Read types of a read workload.
+const (
+	ReadCall    = "call"
+	ReadBalance = "balance"
+	ReadLogs    = "logs"
+)
+
+const (
+	// DefaultLogsBlockRange is the number of the latest blocks whose logs
+	// are read if the read workload has no block range.
+	DefaultLogsBlockRange = 100
+
+	// readTimeout bounds every read. Reads are cancelled when the test is
+	// interrupted, but the reads in flight when the load stops normally
+	// still complete.
+	readTimeout = 30 * time.Second
+
+	// readCodeTimeout is how long the reads of a node wait for the code of
+	// the read contract, e.g. until the contract instance of the write
+	// workload is mined, and readCodeInterval is how often it is checked.
+	readCodeTimeout  = 2 * time.Minute
+	readCodeInterval = time.Second
+)
+
+// readSource is the read API of a node, ethclient.Client satisfies it.
+type readSource interface {
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+}
+
+// readTarget is a node of a read workload. address is the contract instance
+// of the write workload of the node, it is nil if the node has none.
+type readTarget struct {
+	node    *nodeConn
+	address *common.Address
+}
+
+// readLoad issues the reads of a node, the enabled read types are used in
+// turn.
+type readLoad struct {
+	// next is the index of the next read, it is accessed atomically.
+	next uint64
+
+	name    string
+	source  readSource
+	results *logger.TestResults
+	rate    float64
+
+	readTypes []string
+
+	// address is the read contract, it is zero if only balances are read.
+	address common.Address
+
+	// contract and method are used by call reads, the method arguments are
+	// generated for every read.
+	contract *Contract
+	method   string
+	args     *contractArgs
+
+	// accounts are the accounts whose balances are read.
+	accounts []common.Address
+
+	logsBlockRange uint64
+}
+
+// newReadLoad returns the read workload of the given target. The read
+// contract is the address of the read config, the contract instance of the
+// write workload of the node or the contract address of the test profile,
+// in that order. No contract is deployed for the reads.
+func (d *DeployClient) newReadLoad(testProfile *config.TestProfile, target readTarget) (*readLoad, error) {
+	readConfig := testProfile.Reads
+	node := target.node
+
+	rate, err := util.ParseRate(readConfig.Rate)
+	if err != nil {
+		return nil, fmt.Errorf("Error while parsing read rate: %v", err)
+	}
+	if rate <= 0 {
+		return nil, errors.New("read rate must be greater than 0")
+	}
+
+	r := &readLoad{
+		name:           node.name,
+		source:         node.conn,
+		results:        d.Logger.TestResult,
+		rate:           rate,
+		logsBlockRange: readConfig.LogsBlockRange,
+	}
+	if r.logsBlockRange == 0 {
+		r.logsBlockRange = DefaultLogsBlockRange
+	}
+
+	if readConfig.Method != "" {
+		r.readTypes = append(r.readTypes, ReadCall)
+	}
+	if readConfig.Balance {
+		r.readTypes = append(r.readTypes, ReadBalance)
+		for _, sender := range node.senders {
+			r.accounts = append(r.accounts, sender.address)
+		}
+	}
+	if readConfig.Logs {
+		r.readTypes = append(r.readTypes, ReadLogs)
+	}
+	if len(r.readTypes) == 0 {
+		return nil, errors.New("reads need a method, balance or logs")
+	}
+
+	if readConfig.Method == "" && !readConfig.Logs {
+		return r, nil
+	}
+
+	r.contract, err = d.getContract(testProfile)
+	if err != nil {
+		return nil, fmt.Errorf("Error while loading contract: %v", err)
+	}
+	r.address, err = readAddress(readConfig, target, r.contract)
+	if err != nil {
+		return nil, err
+	}
+
+	if readConfig.Method != "" {
+		method, ok := r.contract.ABI.Methods[readConfig.Method]
+		if !ok {
+			return nil, fmt.Errorf("contract has no method %q", readConfig.Method)
+		}
+		r.method = readConfig.Method
+		r.args, err = newContractArgs(method.Inputs, readConfig.MethodArgs, newLockedRand(contractSeed(testProfile)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s arguments: %v", readConfig.Method, err)
+		}
+	}
+	return r, nil
+}
+
+// readAddress returns the address of the contract read by the given target.
+func readAddress(readConfig *config.ReadConfig, target readTarget, contract *Contract) (common.Address, error) {
+	switch {
+	case readConfig.Address != "":
+		if !common.IsHexAddress(readConfig.Address) {
+			return common.Address{}, fmt.Errorf("invalid read address: %q", readConfig.Address)
+		}
+		return common.HexToAddress(readConfig.Address), nil
+	case target.address != nil:
+		return *target.address, nil
+	case contract.Address != nil:
+		return *contract.Address, nil
+	}
+	return common.Address{}, errors.New("reads need an address, the test profile has no contract instance to read")
+}
+
+// waitForCode waits until the read contract has code, so the reads of a
+// contract instance that isn't mined yet don't succeed without running any
+// code. It returns immediately if only balances are read.
+func (r *readLoad) waitForCode(ctx context.Context) error {
+	if r.address == (common.Address{}) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readCodeTimeout)
+	defer cancel()
+	for {
+		code, err := r.source.CodeAt(ctx, r.address, nil)
+		if err == nil && len(code) > 0 {
+			return nil
+		}
+		if !sleepContext(ctx, readCodeInterval) {
+			if err != nil {
+				return fmt.Errorf("Error while fetching code of %s: %v", r.address.Hex(), err)
+			}
+			return fmt.Errorf("%s has no code", r.address.Hex())
+		}
+	}
+}
+
+// contractSeed returns the seed of the argument generators of the contract
+// of the given test profile.
+func contractSeed(testProfile *config.TestProfile) int64 {
+	if testProfile.Contract == nil {
+		return 0
+	}
+	return testProfile.Contract.Seed
+}
+
+// read issues the next read with the given context and returns its type.
+func (r *readLoad) read(ctx context.Context) (string, error) {
+	index := atomic.AddUint64(&r.next, 1) - 1
+	readType := r.readTypes[index%uint64(len(r.readTypes))]
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	switch readType {
+	case ReadCall:
+		args, err := r.args.generate(&argContext{node: r.name, index: index})
+		if err != nil {
+			return readType, err
+		}
+		data, err := r.contract.ABI.Pack(r.method, args...)
+		if err != nil {
+			return readType, err
+		}
+		_, err = r.source.CallContract(ctx, ethereum.CallMsg{To: &r.address, Data: data}, nil)
+		return readType, err
+	case ReadBalance:
+		_, err := r.source.BalanceAt(ctx, r.accounts[index%uint64(len(r.accounts))], nil)
+		return readType, err
+	default:
+		head, err := r.source.BlockNumber(ctx)
+		if err != nil {
+			return readType, err
+		}
+		from := uint64(0)
+		if head >= r.logsBlockRange {
+			from = head - r.logsBlockRange + 1
+		}
+		_, err = r.source.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(head),
+			Addresses: []common.Address{r.address},
+		})
+		return readType, err
+	}
+}
+
+// run issues reads at the target rate until ctx is done and adds their
+// latencies, errors and the achieved read rate to the test results. The
+// reads are cancelled when engineCtx is done, the cancelled reads are not
+// counted.
+func (r *readLoad) run(ctx, engineCtx context.Context) {
+	count, achievedRate := NewScheduler(r.rate).RunUntil(ctx, func(int) {
+		start := time.Now()
+		readType, err := r.read(engineCtx)
+		if err != nil && engineCtx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Debugf("[%s] %s read failed: %v", r.name, readType, err)
+		}
+		r.results.AddRead(readType, time.Since(start), err != nil)
+	})
+	log.Infof("[%s] target read rate: %.2f reads/s, achieved read rate: %.2f reads/s", r.name, r.rate, achievedRate)
+
+	r.results.AddRateResult(logger.RateResult{
+		Name:         fmt.Sprintf("%s - reads", r.name),
+		TxCount:      count,
+		TargetRate:   r.rate,
+		AchievedRate: achievedRate,
+	})
+}
+
+// startReads starts the read workload of the test profile on the given
+// targets, if it has one. The reads of a node start when the read contract
+// has code and run at the same time as the write load until the returned
+// function is called, it waits for the reads in flight.
+func (d *DeployClient) startReads(ctx context.Context, testProfile *config.TestProfile, targets ...readTarget) (func(), error) {
+	if testProfile.Reads == nil {
+		return func() {}, nil
+	}
+
+	var loads []*readLoad
+	for _, target := range targets {
+		load, err := d.newReadLoad(testProfile, target)
+		if err != nil {
+			return nil, fmt.Errorf("Error while preparing reads of [%s] node: %v", target.node.name, err)
+		}
+		loads = append(loads, load)
+	}
+
+	// the reads stop with loadCtx, and the reads in flight are cancelled
+	// with ctx only.
+	loadCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for _, load := range loads {
+		wg.Add(1)
+		go func(load *readLoad) {
+			defer wg.Done()
+			if err := load.waitForCode(loadCtx); err != nil {
+				if loadCtx.Err() == nil {
+					log.Errorf("[%s] Reads are not started: %v", load.name, err)
+				}
+				return
+			}
+			load.run(loadCtx, ctx)
+		}(load)
+	}
+	return func() {
+		cancel()
+		wg.Wait()
+	}, nil
+}
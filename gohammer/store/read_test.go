@@ -0,0 +1,226 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/logger"
+)
+
+// fakeReadSource records the reads of a node, its balance reads fail or
+// hang until they are cancelled if hang is set. Its contract has code after
+// codeChecks code checks.
+type fakeReadSource struct {
+	mu         sync.Mutex
+	calls      [][]byte
+	queries    []ethereum.FilterQuery
+	codeChecks int
+	hang       bool
+}
+
+func (f *fakeReadSource) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, msg.Data)
+	return nil, nil
+}
+
+func (f *fakeReadSource) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	if f.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return nil, errors.New("connection refused")
+}
+
+func (f *fakeReadSource) BlockNumber(ctx context.Context) (uint64, error) {
+	return 250, nil
+}
+
+func (f *fakeReadSource) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.codeChecks > 0 {
+		f.codeChecks--
+		return nil, nil
+	}
+	return []byte{1}, nil
+}
+
+func (f *fakeReadSource) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, q)
+	return nil, nil
+}
+
+func TestNewReadLoad(t *testing.T) {
+	d := NewDeployClient(logger.NewLogClient(nil))
+	node := newTestNodeConn(t, "node1", 2)
+
+	invalid := []*config.ReadConfig{
+		{Balance: true},
+		{Rate: "0/s", Balance: true},
+		{Rate: "10/s"},
+		{Rate: "10/s", Method: "missing", Address: "0x0000000000000000000000000000000000000001"},
+		{Rate: "10/s", Method: "items", Address: "0x0000000000000000000000000000000000000001"},
+		{Rate: "10/s", Method: "version", Address: "invalid"},
+		{Rate: "10/s", Logs: true},
+	}
+	for _, readConfig := range invalid {
+		if _, err := d.newReadLoad(&config.TestProfile{Reads: readConfig}, readTarget{node: node}); err == nil {
+			t.Errorf("newReadLoad(%+v) didn't fail", readConfig)
+		}
+	}
+
+	load, err := d.newReadLoad(&config.TestProfile{Reads: &config.ReadConfig{
+		Rate:       "10/s",
+		Method:     "items",
+		MethodArgs: []interface{}{"0x01"},
+		Balance:    true,
+		Logs:       true,
+		Address:    "0x0000000000000000000000000000000000000001",
+	}}, readTarget{node: node})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(load.readTypes) != 3 || len(load.accounts) != 2 || load.logsBlockRange != DefaultLogsBlockRange {
+		t.Errorf("read load has %v read types, %d accounts and %d block range",
+			load.readTypes, len(load.accounts), load.logsBlockRange)
+	}
+
+	// without an address the contract instance of the write workload is
+	// read, no contract is deployed for the reads.
+	instance := common.HexToAddress("0x02")
+	load, err = d.newReadLoad(&config.TestProfile{Reads: &config.ReadConfig{Rate: "10/s", Method: "version"}},
+		readTarget{node: node, address: &instance})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if load.address != instance {
+		t.Errorf("read address = %s, want the contract instance %s", load.address.Hex(), instance.Hex())
+	}
+}
+
+func TestReadLoadWaitForCode(t *testing.T) {
+	// the contract instance is mined after the first check.
+	source := &fakeReadSource{codeChecks: 1}
+	load := &readLoad{name: "node1", source: source, address: common.HexToAddress("0x01")}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := load.waitForCode(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if source.codeChecks != 0 {
+		t.Errorf("reads started before the contract has code")
+	}
+
+	// reads of balances don't need a contract.
+	load = &readLoad{name: "node1", source: &fakeReadSource{codeChecks: 100}}
+	if err := load.waitForCode(ctx); err != nil {
+		t.Errorf("waitForCode without a contract = %v", err)
+	}
+}
+
+func TestReadLoadRead(t *testing.T) {
+	contract, err := NewStoreContract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := newContractArgs(contract.ABI.Methods["version"].Inputs, nil, newLockedRand(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := &fakeReadSource{}
+	load := &readLoad{
+		name:           "node1",
+		source:         source,
+		readTypes:      []string{ReadCall, ReadBalance, ReadLogs},
+		address:        common.HexToAddress("0x01"),
+		contract:       contract,
+		method:         "version",
+		args:           args,
+		accounts:       []common.Address{{}},
+		logsBlockRange: DefaultLogsBlockRange,
+	}
+
+	for i, want := range []string{ReadCall, ReadBalance, ReadLogs} {
+		readType, err := load.read(context.Background())
+		if readType != want {
+			t.Errorf("read %d is a %s read, want %s", i, readType, want)
+		}
+		if (err != nil) != (readType == ReadBalance) {
+			t.Errorf("%s read returned %v", readType, err)
+		}
+	}
+
+	if len(source.calls) != 1 || string(source.calls[0]) != string(contract.ABI.Methods["version"].ID) {
+		t.Errorf("eth_call data is %x, want the version selector", source.calls)
+	}
+	if len(source.queries) != 1 || source.queries[0].FromBlock.Uint64() != 151 || source.queries[0].ToBlock.Uint64() != 250 {
+		t.Errorf("eth_getLogs queries are %+v, want blocks 151 to 250", source.queries)
+	}
+}
+
+func TestReadLoadRun(t *testing.T) {
+	results := &logger.TestResults{}
+	load := &readLoad{
+		name:      "node1",
+		source:    &fakeReadSource{},
+		results:   results,
+		rate:      200,
+		readTypes: []string{ReadBalance},
+		accounts:  []common.Address{{}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	load.run(ctx, context.Background())
+
+	result := results.ReadResults[ReadBalance]
+	if result == nil || result.Count == 0 || result.ErrorCount != result.Count {
+		t.Fatalf("balance read result is %+v, want only failed reads", result)
+	}
+	if result.Latency.Count() != 0 {
+		t.Errorf("%d failed read latencies are recorded", result.Latency.Count())
+	}
+	if len(results.RateResults) != 1 || results.RateResults[0].TxCount != result.Count {
+		t.Errorf("rate results are %+v, want the %d reads", results.RateResults, result.Count)
+	}
+}
+
+func TestReadLoadRunInterrupted(t *testing.T) {
+	results := &logger.TestResults{}
+	load := &readLoad{
+		name:      "node1",
+		source:    &fakeReadSource{hang: true},
+		results:   results,
+		rate:      200,
+		readTypes: []string{ReadBalance},
+		accounts:  []common.Address{{}},
+	}
+
+	// the test is interrupted while the reads hang.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	load.run(ctx, ctx)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("reads in flight were waited for %s after the interrupt", elapsed)
+	}
+	if result := results.ReadResults[ReadBalance]; result != nil {
+		t.Errorf("cancelled reads are counted: %+v", result)
+	}
+}
@@ -0,0 +1,234 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/logger"
+	"github.com/tubuarge/GoHammer/util"
+)
+
+const (
+	DefaultReceiptPollInterval = time.Second
+	DefaultReceiptTimeout      = 2 * time.Minute
+
+	// receiptRequestTimeout bounds every receipt request, so a hung request
+	// doesn't stop the polling of the other transactions.
+	receiptRequestTimeout = 10 * time.Second
+)
+
+// receiptSource returns the receipt of a mined transaction, ethclient.Client
+// satisfies it.
+type receiptSource interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// ReceiptTracker polls the receipts of the submitted transactions of a node
+// and adds their inclusion latency and status to the test results.
+// Transactions without a receipt after the timeout are counted as dropped.
+type ReceiptTracker struct {
+	name    string
+	source  receiptSource
+	results *logger.TestResults
+
+	// stats counts the mined transactions of the test profile, it is nil
+	// if they are not counted.
+	stats *profileStats
+
+	pollInterval time.Duration
+	timeout      time.Duration
+
+	mu      sync.Mutex
+	pending map[common.Hash]pendingTx
+
+	quit chan struct{}
+	done chan struct{}
+
+	// ctx is cancelled by abort, the receipt requests in flight are
+	// cancelled with it.
+	ctx   context.Context
+	abort context.CancelFunc
+}
+
+// pendingTx is a transaction waiting for its receipt.
+type pendingTx struct {
+	gasLimit    uint64
+	submittedAt time.Time
+}
+
+// NewReceiptTracker returns a started receipt tracker, Wait has to be called
+// to stop it.
+func NewReceiptTracker(name string, source receiptSource, results *logger.TestResults,
+	pollInterval, timeout time.Duration) *ReceiptTracker {
+	r := &ReceiptTracker{
+		name:         name,
+		source:       source,
+		results:      results,
+		pollInterval: pollInterval,
+		timeout:      timeout,
+		pending:      make(map[common.Hash]pendingTx),
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	r.ctx, r.abort = context.WithCancel(context.Background())
+	go r.loop()
+	return r
+}
+
+// receiptTrackerFromConfig returns a receipt tracker for the given node
+// according to the test profile receipt config, or nil if receipts are not
+// tracked.
+func receiptTrackerFromConfig(name string, source receiptSource, results *logger.TestResults,
+	stats *profileStats, receiptConfig *config.ReceiptConfig) (*ReceiptTracker, error) {
+	if receiptConfig == nil {
+		return nil, nil
+	}
+
+	pollInterval := DefaultReceiptPollInterval
+	if receiptConfig.PollInterval != "" {
+		var err error
+		pollInterval, err = util.ParseDuration(receiptConfig.PollInterval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	timeout := DefaultReceiptTimeout
+	if receiptConfig.Timeout != "" {
+		var err error
+		timeout, err = util.ParseDuration(receiptConfig.Timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r := NewReceiptTracker(name, source, results, pollInterval, timeout)
+	// stats is read only after a transaction is tracked.
+	r.stats = stats
+	return r, nil
+}
+
+// Track adds the given transaction to the pending transactions. The gas
+// limit is used to tell failed transactions that ran out of gas.
+func (r *ReceiptTracker) Track(txHash common.Hash, gasLimit uint64, submittedAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[txHash] = pendingTx{gasLimit: gasLimit, submittedAt: submittedAt}
+}
+
+// Pending returns the number of transactions waiting for a receipt.
+func (r *ReceiptTracker) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pending)
+}
+
+// Wait blocks until every tracked transaction is either mined or dropped,
+// then stops the tracker. If the given abort channel is closed before that,
+// the tracker stops without waiting for the pending transactions and they
+// are counted as unconfirmed.
+func (r *ReceiptTracker) Wait(abort <-chan struct{}) {
+	if pending := r.Pending(); pending > 0 {
+		log.Infof("[%s] Waiting for %d transaction receipts...", r.name, pending)
+	}
+	close(r.quit)
+
+	select {
+	case <-r.done:
+		return
+	case <-abort:
+	}
+	r.abort()
+	<-r.done
+
+	if pending := r.Pending(); pending > 0 {
+		log.Warnf("[%s] Stopped waiting for %d transaction receipts.", r.name, pending)
+		r.results.AddUnconfirmedTxs(pending)
+	}
+}
+
+func (r *ReceiptTracker) loop() {
+	defer close(r.done)
+	defer r.abort()
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	quit := r.quit
+	for {
+		select {
+		case <-quit:
+			// keep polling until every pending transaction is done.
+			quit = nil
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+			r.poll()
+		}
+
+		if quit == nil && r.Pending() == 0 {
+			return
+		}
+	}
+}
+
+// poll fetches the receipts of the pending transactions.
+func (r *ReceiptTracker) poll() {
+	r.mu.Lock()
+	pending := make(map[common.Hash]pendingTx, len(r.pending))
+	for txHash, tx := range r.pending {
+		pending[txHash] = tx
+	}
+	r.mu.Unlock()
+
+	for txHash, tx := range pending {
+		if r.ctx.Err() != nil {
+			return
+		}
+		receipt, err := r.receipt(txHash)
+		if err != nil && r.ctx.Err() != nil {
+			// the request is cancelled by abort, the transaction stays
+			// pending.
+			return
+		}
+		now := time.Now()
+
+		switch {
+		case err == nil && receipt.Status == types.ReceiptStatusFailed && receipt.GasUsed >= tx.gasLimit:
+			// a failed transaction that used all of its gas ran out of gas.
+			r.results.AddMinedTx(r.name, now.Sub(tx.submittedAt), true)
+			r.results.AddOutOfGasTx()
+			r.stats.addMined()
+		case err == nil:
+			r.results.AddMinedTx(r.name, now.Sub(tx.submittedAt), receipt.Status == types.ReceiptStatusFailed)
+			r.stats.addMined()
+		case now.Sub(tx.submittedAt) >= r.timeout:
+			log.Warnf("[%s] No receipt for %s after %s, transaction is dropped.", r.name, txHash.Hex(), r.timeout)
+			r.results.AddDroppedTx()
+		case err == ethereum.NotFound:
+			continue
+		default:
+			log.Errorf("[%s] Error while fetching receipt of %s: %v", r.name, txHash.Hex(), err)
+			continue
+		}
+
+		r.mu.Lock()
+		delete(r.pending, txHash)
+		r.mu.Unlock()
+	}
+}
+
+// receipt fetches the receipt of the given transaction, the request is
+// cancelled when the tracker is aborted.
+func (r *ReceiptTracker) receipt(txHash common.Hash) (*types.Receipt, error) {
+	ctx, cancel := context.WithTimeout(r.ctx, receiptRequestTimeout)
+	defer cancel()
+	return r.source.TransactionReceipt(ctx, txHash)
+}
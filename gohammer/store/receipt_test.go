@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tubuarge/GoHammer/logger"
+)
+
+type fakeReceipts map[common.Hash]*types.Receipt
+
+func (f fakeReceipts) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, ok := f[txHash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func TestReceiptTracker(t *testing.T) {
+	mined := common.HexToHash("0x01")
+	reverted := common.HexToHash("0x02")
+	dropped := common.HexToHash("0x03")
+	outOfGas := common.HexToHash("0x04")
+
+	source := fakeReceipts{
+		mined:    {Status: types.ReceiptStatusSuccessful, GasUsed: 21000},
+		reverted: {Status: types.ReceiptStatusFailed, GasUsed: 25000},
+		outOfGas: {Status: types.ReceiptStatusFailed, GasUsed: 30000},
+	}
+	results := &logger.TestResults{}
+
+	tracker := NewReceiptTracker("test", source, results, 5*time.Millisecond, 50*time.Millisecond)
+	for _, txHash := range []common.Hash{mined, reverted, dropped, outOfGas} {
+		tracker.Track(txHash, 30000, time.Now())
+	}
+	tracker.Wait(nil)
+
+	// the out of gas transaction is counted as reverted too.
+	if results.MinedTxCount != 1 || results.RevertedTxCount != 2 || results.DroppedTxCount != 1 || results.OutOfGasTxCount != 1 {
+		t.Errorf("mined: %d, reverted: %d, dropped: %d, out of gas: %d, want 1, 2, 1 and 1",
+			results.MinedTxCount, results.RevertedTxCount, results.DroppedTxCount, results.OutOfGasTxCount)
+	}
+	if tracker.Pending() != 0 {
+		t.Errorf("%d transactions are still pending", tracker.Pending())
+	}
+}
+
+func TestReceiptTrackerWaitAbort(t *testing.T) {
+	results := &logger.TestResults{}
+	tracker := NewReceiptTracker("test", fakeReceipts{}, results, 5*time.Millisecond, time.Hour)
+	tracker.Track(common.HexToHash("0x01"), 30000, time.Now())
+	tracker.Track(common.HexToHash("0x02"), 30000, time.Now())
+
+	abort := make(chan struct{})
+	time.AfterFunc(20*time.Millisecond, func() { close(abort) })
+	tracker.Wait(abort)
+
+	if results.UnconfirmedTxCount != 2 || results.DroppedTxCount != 0 {
+		t.Errorf("unconfirmed: %d, dropped: %d, want 2 unconfirmed", results.UnconfirmedTxCount, results.DroppedTxCount)
+	}
+}
+
+// hungReceipts never answers a receipt request before it is cancelled.
+type hungReceipts struct{}
+
+func (hungReceipts) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestReceiptTrackerWaitAbortHungRequest(t *testing.T) {
+	results := &logger.TestResults{}
+	tracker := NewReceiptTracker("test", hungReceipts{}, results, 5*time.Millisecond, time.Hour)
+	tracker.Track(common.HexToHash("0x01"), 30000, time.Now())
+
+	abort := make(chan struct{})
+	time.AfterFunc(20*time.Millisecond, func() { close(abort) })
+	start := time.Now()
+	tracker.Wait(abort)
+
+	// the hung request is cancelled instead of waiting for its timeout.
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Wait returned %s after the abort", elapsed)
+	}
+	if results.UnconfirmedTxCount != 1 || results.DroppedTxCount != 0 {
+		t.Errorf("unconfirmed: %d, dropped: %d, want 1 unconfirmed", results.UnconfirmedTxCount, results.DroppedTxCount)
+	}
+}
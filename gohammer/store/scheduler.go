@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// schedulerStep is the resolution used while integrating the rate function
+// to find the next transaction on the timeline.
+const schedulerStep = time.Millisecond
+
+// RateFunc returns the target rate in transactions per second at the given
+// time offset from the start of a run.
+type RateFunc func(elapsed time.Duration) float64
+
+// ConstantRate returns a RateFunc that always returns the given rate.
+func ConstantRate(rate float64) RateFunc {
+	return func(time.Duration) float64 {
+		return rate
+	}
+}
+
+// Scheduler issues transactions on a fixed timeline derived from a target
+// rate. The time of every transaction is computed from the start of the run
+// and the rate function only, no matter how long the previous transactions
+// took to send, so the offered load doesn't depend on how fast the node
+// answers (open-loop).
+type Scheduler struct {
+	RateFunc RateFunc
+}
+
+// NewScheduler returns a Scheduler with a constant rate in transactions per
+// second.
+func NewScheduler(rate float64) *Scheduler {
+	return NewShapedScheduler(ConstantRate(rate))
+}
+
+// NewShapedScheduler returns a Scheduler whose rate changes over time
+// according to the given rate function.
+func NewShapedScheduler(rateFunc RateFunc) *Scheduler {
+	return &Scheduler{RateFunc: rateFunc}
+}
+
+// Run calls send count times on the scheduler timeline. Every call runs on
+// its own goroutine so a slow send never delays the following ones.
+// Run waits for all calls to return and returns the number of issued calls
+// and the achieved rate in transactions per second, which is measured from
+// the issue times of the calls so slow sends don't lower it. No more calls
+// are issued after ctx is done, so fewer than count calls may be issued.
+func (s *Scheduler) Run(ctx context.Context, count int, send func(i int)) (int, float64) {
+	return s.run(ctx, 0, func(i int, _ time.Duration) bool {
+		return i < count
+	}, send)
+}
+
+// RunFor calls send on the scheduler timeline until the given duration is
+// elapsed or ctx is done. It returns the number of issued transactions and
+// the achieved rate in transactions per second.
+func (s *Scheduler) RunFor(ctx context.Context, duration time.Duration, send func(i int)) (int, float64) {
+	return s.run(ctx, duration, func(_ int, offset time.Duration) bool {
+		return offset < duration
+	}, send)
+}
+
+// RunUntil calls send on the scheduler timeline until ctx is done. It
+// returns the number of issued calls and the achieved rate in calls per
+// second.
+func (s *Scheduler) RunUntil(ctx context.Context, send func(i int)) (int, float64) {
+	return s.run(ctx, 0, func(int, time.Duration) bool {
+		return true
+	}, send)
+}
+
+func (s *Scheduler) run(ctx context.Context, limit time.Duration, more func(i int, offset time.Duration) bool, send func(i int)) (int, float64) {
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	count := 0
+	// lastIssue is the offset of the last issued call, the achieved rate
+	// is measured up to it and not up to the end of the slowest send.
+	var lastIssue time.Duration
+	for offset := time.Duration(0); more(count, offset); offset = s.nextOffset(offset, limit) {
+		if !sleepContext(ctx, time.Until(start.Add(offset))) {
+			break
+		}
+
+		lastIssue = time.Since(start)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			send(i)
+		}(count)
+		count++
+	}
+	wg.Wait()
+
+	return count, achievedRate(count, lastIssue)
+}
+
+// sleepContext sleeps for the given duration, it returns false if ctx is
+// done before that.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// nextOffset returns the offset of the transaction following the one at the
+// given offset, that is where the integral of the rate function reaches one
+// transaction. If limit is greater than zero, the search stops there.
+func (s *Scheduler) nextOffset(offset, limit time.Duration) time.Duration {
+	credit := 0.0
+	for t := offset; limit <= 0 || t < limit; t += schedulerStep {
+		rate := s.RateFunc(t)
+		stepCredit := rate * schedulerStep.Seconds()
+		if credit+stepCredit >= 1 {
+			return t + time.Duration((1-credit)/rate*float64(time.Second))
+		}
+		credit += stepCredit
+	}
+	return limit
+}
+
+// ExpectedCount returns the number of transactions the scheduler timeline
+// has in the given duration, that is the integral of the rate function over
+// it.
+func (s *Scheduler) ExpectedCount(duration time.Duration) float64 {
+	count := 0.0
+	for t := time.Duration(0); t < duration; t += schedulerStep {
+		step := schedulerStep
+		if t+step > duration {
+			step = duration - t
+		}
+		count += s.RateFunc(t) * step.Seconds()
+	}
+	return count
+}
+
+// achievedRate returns the number of transactions per second for count
+// transactions issued from the first one to the last one in the given
+// duration, that is count-1 intervals. It returns 0 if fewer than two
+// transactions are issued.
+func achievedRate(count int, elapsed time.Duration) float64 {
+	if count < 2 || elapsed <= 0 {
+		return 0
+	}
+	return float64(count-1) / elapsed.Seconds()
+}
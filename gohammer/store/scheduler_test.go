@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+func TestSchedulerNextOffset(t *testing.T) {
+	ramp, err := newLoadShape(&config.Phase{
+		Type:     PhaseRamp,
+		Duration: "1s",
+		From:     "0",
+		To:       "100/s",
+	}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		scheduler *Scheduler
+		offset    time.Duration
+		limit     time.Duration
+		want      time.Duration
+	}{
+		{"constant", NewScheduler(100), 0, 0, 10 * time.Millisecond},
+		{"constant from offset", NewScheduler(4), time.Second, 0, 1250 * time.Millisecond},
+		// 50*t^2 transactions are due at t, so the first one is at sqrt(1/50)s.
+		{"ramp", NewShapedScheduler(ramp.rateFunc), 0, 0, 141421 * time.Microsecond},
+		{"limit", NewScheduler(1), 0, 500 * time.Millisecond, 500 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		got := test.scheduler.nextOffset(test.offset, test.limit)
+		diff := got - test.want
+		if diff < -schedulerStep || diff > schedulerStep {
+			t.Errorf("%s: nextOffset = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSchedulerExpectedCount(t *testing.T) {
+	ramp, err := newLoadShape(&config.Phase{
+		Type:     PhaseRamp,
+		Duration: "10s",
+		From:     "0",
+		To:       "100/s",
+	}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		scheduler *Scheduler
+		duration  time.Duration
+		want      float64
+	}{
+		{"constant", NewScheduler(100), 10 * time.Second, 1000},
+		{"partial step", NewScheduler(1000), 2500 * time.Microsecond, 2.5},
+		// the ramp averages 50 tx/s over 10s.
+		{"ramp", NewShapedScheduler(ramp.rateFunc), 10 * time.Second, 500},
+	}
+
+	for _, test := range tests {
+		got := test.scheduler.ExpectedCount(test.duration)
+		if diff := got - test.want; diff < -0.1 || diff > 0.1 {
+			t.Errorf("%s: ExpectedCount = %.2f, want %.2f", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSchedulerRunDoesNotWaitForSend(t *testing.T) {
+	var mu sync.Mutex
+	var issued []time.Duration
+
+	start := time.Now()
+	// every send takes longer than the whole run would take if the
+	// scheduler waited for it.
+	NewScheduler(100).Run(context.Background(), 10, func(int) {
+		mu.Lock()
+		issued = append(issued, time.Since(start))
+		mu.Unlock()
+		time.Sleep(200 * time.Millisecond)
+	})
+
+	if len(issued) != 10 {
+		t.Fatalf("issued %d transactions, want 10", len(issued))
+	}
+	for _, offset := range issued {
+		if offset > 150*time.Millisecond {
+			t.Errorf("transaction issued at %s, scheduler waited for previous sends", offset)
+		}
+	}
+}
+
+func TestSchedulerAchievedRateExcludesSendTail(t *testing.T) {
+	// the sends take longer than the whole run, the achieved rate is still
+	// the offered rate.
+	_, rate := NewScheduler(100).Run(context.Background(), 20, func(int) {
+		time.Sleep(500 * time.Millisecond)
+	})
+	if rate < 90 || rate > 110 {
+		t.Errorf("achieved rate = %.2f tx/s, want about 100 tx/s", rate)
+	}
+}
+
+func TestSchedulerRunReturnsIssuedCount(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// the run is stopped before all transactions are issued.
+	count, _ := NewScheduler(100).Run(ctx, 1000, func(int) {})
+	if count == 0 || count > 10 {
+		t.Errorf("issued %d transactions, want about 5", count)
+	}
+}
+
+func TestSchedulerRunStopsOnContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	count, _ := NewScheduler(100).RunFor(ctx, time.Minute, func(int) {})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("run took %s after its context was done", elapsed)
+	}
+	if count == 0 || count > 10 {
+		t.Errorf("issued %d transactions, want about 5", count)
+	}
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+// Signer signs the transactions of a sender account.
+type Signer interface {
+	// Address returns the address of the account.
+	Address() common.Address
+
+	// SignTx returns the given transaction signed for the given chain.
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// LocalSigner signs transactions with a private key held by GoHammer.
+type LocalSigner struct {
+	privateKey *ecdsa.PrivateKey
+	address    common.Address
+}
+
+func NewLocalSigner(privateKey *ecdsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{
+		privateKey: privateKey,
+		address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+	}
+}
+
+func (s *LocalSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *LocalSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.privateKey)
+}
+
+// ClefSigner signs transactions with the account_signTransaction method of
+// an external signer like Clef, so the private key never leaves the signer.
+type ClefSigner struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+func NewClefSigner(client *rpc.Client, address common.Address) *ClefSigner {
+	return &ClefSigner{client: client, address: address}
+}
+
+// clefTxArgs are the transaction arguments of account_signTransaction.
+type clefTxArgs struct {
+	From                 common.Address    `json:"from"`
+	To                   *common.Address   `json:"to"`
+	Gas                  hexutil.Uint64    `json:"gas"`
+	GasPrice             *hexutil.Big      `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big      `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big      `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big       `json:"value"`
+	Nonce                hexutil.Uint64    `json:"nonce"`
+	Data                 hexutil.Bytes     `json:"data"`
+	AccessList           *types.AccessList `json:"accessList,omitempty"`
+	ChainID              *hexutil.Big      `json:"chainId,omitempty"`
+}
+
+type clefSignTxResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+func (s *ClefSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *ClefSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := clefTxArgs{
+		From:    s.address,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	switch tx.Type() {
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	case types.AccessListTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+		// the signer makes an access list transaction only if the access
+		// list is set, even if it is empty.
+		accessList := tx.AccessList()
+		if accessList == nil {
+			accessList = types.AccessList{}
+		}
+		args.AccessList = &accessList
+	default:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	}
+
+	var result clefSignTxResult
+	if err := s.client.CallContext(ctx, &result, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("Error while signing transaction with external signer: %v", err)
+	}
+
+	signedTx := new(types.Transaction)
+	if err := signedTx.UnmarshalBinary(result.Raw); err != nil {
+		return nil, fmt.Errorf("Error while decoding signed transaction: %v", err)
+	}
+
+	// the signer may let its user change the transaction, which would
+	// break the nonce sequence of the sender.
+	signer := types.LatestSignerForChainID(chainID)
+	if signer.Hash(signedTx) != signer.Hash(tx) {
+		return nil, errors.New("external signer changed the transaction")
+	}
+	from, err := types.Sender(signer, signedTx)
+	if err != nil {
+		return nil, fmt.Errorf("Error while verifying signed transaction: %v", err)
+	}
+	if from != s.address {
+		return nil, fmt.Errorf("external signer signed with %s instead of %s", from.Hex(), s.address.Hex())
+	}
+	return signedTx, nil
+}
+
+// newClefSigners connects to the external signer of a node and returns a
+// signer for every configured account. The connection is shared by the
+// nodes with the same signer URL, the returned release function must be
+// called when the load of the node is finished.
+func (d *DeployClient) newClefSigners(signerConfig *config.SignerConfig) ([]Signer, func(), error) {
+	if signerConfig.URL == "" {
+		return nil, nil, errors.New("signer url is required")
+	}
+	if len(signerConfig.Accounts) == 0 {
+		return nil, nil, errors.New("signer accounts are required")
+	}
+
+	var addresses []common.Address
+	for _, account := range signerConfig.Accounts {
+		if !common.IsHexAddress(account) {
+			return nil, nil, fmt.Errorf("invalid signer account: %q", account)
+		}
+		addresses = append(addresses, common.HexToAddress(account))
+	}
+
+	client, release, err := d.dialSigner(signerConfig.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error while connecting to external signer: %v", err)
+	}
+
+	var signers []Signer
+	for _, address := range addresses {
+		signers = append(signers, NewClefSigner(client, address))
+	}
+	return signers, release, nil
+}
+
+// signerConn is a connection to an external signer and the number of nodes
+// that use it.
+type signerConn struct {
+	client *rpc.Client
+	refs   int
+}
+
+// dialSigner returns the connection to the external signer at the given
+// URL. It is dialed with the rpc client of the deploy client once and
+// closed when the last node that uses it calls the returned release
+// function.
+func (d *DeployClient) dialSigner(url string) (*rpc.Client, func(), error) {
+	d.signerConnsMu.Lock()
+	defer d.signerConnsMu.Unlock()
+
+	conn, ok := d.signerConns[url]
+	if !ok {
+		client, err := d.createConn(url)
+		if err != nil {
+			return nil, nil, err
+		}
+		conn = &signerConn{client: client}
+		d.signerConns[url] = conn
+	}
+	conn.refs++
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			d.signerConnsMu.Lock()
+			defer d.signerConnsMu.Unlock()
+			conn.refs--
+			if conn.refs == 0 {
+				conn.client.Close()
+				delete(d.signerConns, url)
+			}
+		})
+	}
+	return conn.client, release, nil
+}
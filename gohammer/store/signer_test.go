@@ -0,0 +1,226 @@
+package store
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+// mockClef is the account API of a mock external signer, it signs with its
+// key like Clef after an optional change of the transaction.
+type mockClef struct {
+	privateKey *ecdsa.PrivateKey
+	chainID    *big.Int
+	modify     func(args *clefTxArgs)
+}
+
+func (m *mockClef) SignTransaction(args clefTxArgs) (*clefSignTxResult, error) {
+	if m.modify != nil {
+		m.modify(&args)
+	}
+
+	to := args.To
+	var txData types.TxData
+	switch {
+	case args.MaxFeePerGas != nil:
+		txData = &types.DynamicFeeTx{
+			ChainID:    (*big.Int)(args.ChainID),
+			Nonce:      uint64(args.Nonce),
+			GasTipCap:  (*big.Int)(args.MaxPriorityFeePerGas),
+			GasFeeCap:  (*big.Int)(args.MaxFeePerGas),
+			Gas:        uint64(args.Gas),
+			To:         to,
+			Value:      (*big.Int)(&args.Value),
+			Data:       args.Data,
+			AccessList: *args.AccessList,
+		}
+	case args.AccessList != nil:
+		txData = &types.AccessListTx{
+			ChainID:    (*big.Int)(args.ChainID),
+			Nonce:      uint64(args.Nonce),
+			GasPrice:   (*big.Int)(args.GasPrice),
+			Gas:        uint64(args.Gas),
+			To:         to,
+			Value:      (*big.Int)(&args.Value),
+			Data:       args.Data,
+			AccessList: *args.AccessList,
+		}
+	default:
+		txData = &types.LegacyTx{
+			Nonce:    uint64(args.Nonce),
+			GasPrice: (*big.Int)(args.GasPrice),
+			Gas:      uint64(args.Gas),
+			To:       to,
+			Value:    (*big.Int)(&args.Value),
+			Data:     args.Data,
+		}
+	}
+
+	tx, err := types.SignTx(types.NewTx(txData), types.LatestSignerForChainID(m.chainID), m.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &clefSignTxResult{Raw: raw}, nil
+}
+
+// newMockClefSigner starts a mock external signer and returns a signer of
+// its account.
+func newMockClefSigner(t *testing.T, clef *mockClef) (*ClefSigner, func()) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", clef); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+
+	client, err := rpc.DialHTTP(httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewClefSigner(client, crypto.PubkeyToAddress(clef.privateKey.PublicKey))
+	return signer, func() {
+		client.Close()
+		httpServer.Close()
+		server.Stop()
+	}
+}
+
+func TestClefSignerSignTx(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(1337)
+	signer, stop := newMockClefSigner(t, &mockClef{privateKey: privateKey, chainID: chainID})
+	defer stop()
+
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	txs := []*types.Transaction{
+		types.NewTx(&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1000), Gas: 21000, To: &to, Value: big.NewInt(7)}),
+		types.NewTx(&types.LegacyTx{Nonce: 2, GasPrice: big.NewInt(1000), Gas: 300000, Data: []byte{1, 2}}),
+		types.NewTx(&types.AccessListTx{ChainID: chainID, Nonce: 3, GasPrice: big.NewInt(1000), Gas: 21000, To: &to}),
+		types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 4, GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(2000), Gas: 21000, To: &to,
+			AccessList: types.AccessList{{Address: to, StorageKeys: []common.Hash{{1}}}}}),
+	}
+	for _, tx := range txs {
+		signedTx, err := signer.SignTx(context.Background(), tx, chainID)
+		if err != nil {
+			t.Errorf("nonce %d: %v", tx.Nonce(), err)
+			continue
+		}
+		if signedTx.Type() != tx.Type() || signedTx.Nonce() != tx.Nonce() {
+			t.Errorf("nonce %d: signed transaction type %d nonce %d, want type %d", tx.Nonce(), signedTx.Type(), signedTx.Nonce(), tx.Type())
+		}
+		from, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+		if err != nil || from != signer.Address() {
+			t.Errorf("nonce %d: transaction sender = %s (%v), want %s", tx.Nonce(), from.Hex(), err, signer.Address().Hex())
+		}
+	}
+}
+
+func TestClefSignerVerify(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(1337)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1000), Gas: 21000, To: &to})
+
+	// the signer changes the nonce.
+	signer, stop := newMockClefSigner(t, &mockClef{
+		privateKey: privateKey,
+		chainID:    chainID,
+		modify:     func(args *clefTxArgs) { args.Nonce = hexutil.Uint64(2) },
+	})
+	if _, err := signer.SignTx(context.Background(), tx, chainID); err == nil {
+		t.Error("SignTx didn't fail on a changed transaction")
+	}
+	stop()
+
+	// the signer signs with another account.
+	signer, stop = newMockClefSigner(t, &mockClef{privateKey: privateKey, chainID: chainID})
+	defer stop()
+	signer.address = common.HexToAddress("0x01")
+	if _, err := signer.SignTx(context.Background(), tx, chainID); err == nil {
+		t.Error("SignTx didn't fail on a transaction signed by another account")
+	}
+}
+
+func TestLocalSigner(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewLocalSigner(privateKey)
+
+	tx, err := signer.SignTx(context.Background(), types.NewTx(&types.LegacyTx{Gas: 21000, GasPrice: big.NewInt(1)}), big.NewInt(1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1337)), tx)
+	if err != nil || from != signer.Address() {
+		t.Errorf("transaction sender = %s (%v), want %s", from.Hex(), err, signer.Address().Hex())
+	}
+}
+
+func TestNewClefSignersValidation(t *testing.T) {
+	invalid := []*config.SignerConfig{
+		{Accounts: []string{"0x00000000000000000000000000000000000000aa"}},
+		{URL: "http://localhost:8550"},
+		{URL: "http://localhost:8550", Accounts: []string{"0x1234"}},
+	}
+	d := NewDeployClient(nil)
+	for _, signerConfig := range invalid {
+		if _, _, err := d.newClefSigners(signerConfig); err == nil {
+			t.Errorf("newClefSigners(%+v) didn't fail", signerConfig)
+		}
+	}
+	if len(d.signerConns) != 0 {
+		t.Errorf("invalid signer configs left %d signer connections", len(d.signerConns))
+	}
+}
+
+func TestNewClefSignersSharesConnection(t *testing.T) {
+	d := NewDeployClient(nil)
+	signerConfig := &config.SignerConfig{
+		URL:      "http://localhost:8550",
+		Accounts: []string{"0x00000000000000000000000000000000000000aa"},
+	}
+
+	// two nodes with the same external signer.
+	first, releaseFirst, err := d.newClefSigners(signerConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, releaseSecond, err := d.newClefSigners(signerConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first[0].(*ClefSigner).client != second[0].(*ClefSigner).client {
+		t.Error("the external signer is dialed once per node")
+	}
+
+	releaseFirst()
+	releaseFirst()
+	if conn := d.signerConns[signerConfig.URL]; conn == nil || conn.refs != 1 {
+		t.Errorf("signer connection is %+v after the first node is finished, want 1 reference", conn)
+	}
+	releaseSecond()
+	if len(d.signerConns) != 0 {
+		t.Error("signer connection isn't closed after every node is finished")
+	}
+}
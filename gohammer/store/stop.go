@@ -0,0 +1,248 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/logger"
+	"github.com/tubuarge/GoHammer/util"
+)
+
+const (
+	// stopCheckInterval is how often the stop conditions of a test profile
+	// are checked.
+	stopCheckInterval = time.Second
+
+	DefaultStopMinTxs = 100
+)
+
+// profileStats counts the transactions of a test profile for its stop
+// conditions, a nil profileStats counts nothing.
+type profileStats struct {
+	sent   int64
+	failed int64
+	mined  int64
+}
+
+func (s *profileStats) addSent() {
+	if s != nil {
+		atomic.AddInt64(&s.sent, 1)
+	}
+}
+
+func (s *profileStats) addFailed() {
+	if s != nil {
+		atomic.AddInt64(&s.failed, 1)
+	}
+}
+
+func (s *profileStats) addMined() {
+	if s != nil {
+		atomic.AddInt64(&s.mined, 1)
+	}
+}
+
+// blockNumberSource returns the latest block number of the chain,
+// ethclient.Client satisfies it.
+type blockNumberSource interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// stopConditions stop a test profile when one of them is met.
+type stopConditions struct {
+	duration    time.Duration
+	minedTxs    int64
+	errorRate   float64
+	minTxs      int64
+	blockNumber uint64
+
+	stats *profileStats
+
+	// head is nil if the block number isn't checked.
+	head blockNumberSource
+}
+
+func newStopConditions(stopConfig *config.StopConfig, receipts bool, stats *profileStats) (*stopConditions, error) {
+	s := &stopConditions{
+		minedTxs:    int64(stopConfig.MinedTxs),
+		errorRate:   stopConfig.ErrorRate,
+		minTxs:      DefaultStopMinTxs,
+		blockNumber: stopConfig.BlockNumber,
+		stats:       stats,
+	}
+
+	if stopConfig.Duration != "" {
+		duration, err := util.ParseDuration(stopConfig.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration: %v", err)
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("duration has to be positive: %s", stopConfig.Duration)
+		}
+		s.duration = duration
+	}
+	if stopConfig.MinedTxs < 0 {
+		return nil, fmt.Errorf("minedTxs can't be negative: %d", stopConfig.MinedTxs)
+	}
+	if stopConfig.MinedTxs > 0 && !receipts {
+		return nil, errors.New("minedTxs requires receipts to be tracked")
+	}
+	if stopConfig.ErrorRate < 0 || stopConfig.ErrorRate > 1 {
+		return nil, fmt.Errorf("errorRate has to be between 0 and 1: %v", stopConfig.ErrorRate)
+	}
+	if stopConfig.MinTxs < 0 {
+		return nil, fmt.Errorf("minTxs can't be negative: %d", stopConfig.MinTxs)
+	}
+	if stopConfig.MinTxs > 0 {
+		s.minTxs = int64(stopConfig.MinTxs)
+	}
+
+	if s.duration == 0 && s.minedTxs == 0 && s.errorRate == 0 && s.blockNumber == 0 {
+		return nil, errors.New("no stop condition is set")
+	}
+	return s, nil
+}
+
+// newStopConditions returns the stop conditions of the given test profile,
+// or nil if it has none. The returned close function closes the connection
+// used to fetch the block number.
+func (d *DeployClient) newStopConditions(testProfile *config.TestProfile) (*stopConditions, func(), error) {
+	if testProfile.Stop == nil {
+		return nil, func() {}, nil
+	}
+	s, err := newStopConditions(testProfile.Stop, testProfile.Receipts != nil, d.profileStats(testProfile))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error while parsing stop conditions: %v", err)
+	}
+	if s.blockNumber == 0 {
+		return s, func() {}, nil
+	}
+
+	if len(testProfile.Nodes) == 0 {
+		return nil, nil, errors.New("Error while parsing stop conditions: blockNumber requires a node")
+	}
+	rpcClient, err := d.createConn(testProfile.Nodes[0].URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error while creating ETH Client Connection: %v", err)
+	}
+	s.head = ethclient.NewClient(rpcClient)
+	return s, rpcClient.Close, nil
+}
+
+// watchStopConditions stops the given test profile without an error when
+// one of its stop conditions is met. The returned function stops watching.
+func (d *DeployClient) watchStopConditions(testProfile *config.TestProfile, profile *stopper) (func(), error) {
+	s, closeHead, err := d.newStopConditions(testProfile)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return func() {}, nil
+	}
+
+	ctx, cancel := context.WithCancel(profile.ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.watch(ctx, func(reason string) {
+			log.Infof("[%s] Stopping test profile, %s.", testProfile.Name, reason)
+			d.Logger.WriteTestEntry(
+				fmt.Sprintf("Stop condition is met: %s.", reason),
+				testProfile.Name,
+				time.Now(),
+				logger.SeperatorNone,
+			)
+			profile.stop(nil)
+		})
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		closeHead()
+	}, nil
+}
+
+// profileStats returns the transaction counts of the given test profile.
+func (d *DeployClient) profileStats(testProfile *config.TestProfile) *profileStats {
+	d.statsMu.Lock()
+	defer d.statsMu.Unlock()
+
+	stats, ok := d.stats[testProfile]
+	if !ok {
+		stats = &profileStats{}
+		d.stats[testProfile] = stats
+	}
+	return stats
+}
+
+// check returns why the test profile has to stop after the given elapsed
+// time, or an empty string if no condition is met.
+func (s *stopConditions) check(ctx context.Context, elapsed time.Duration) string {
+	if s.duration > 0 && elapsed >= s.duration {
+		return fmt.Sprintf("duration of %s is reached", s.duration)
+	}
+
+	if mined := atomic.LoadInt64(&s.stats.mined); s.minedTxs > 0 && mined >= s.minedTxs {
+		return fmt.Sprintf("%d transactions are mined", mined)
+	}
+
+	if s.errorRate > 0 {
+		failed := atomic.LoadInt64(&s.stats.failed)
+		total := failed + atomic.LoadInt64(&s.stats.sent)
+		if total >= s.minTxs && float64(failed)/float64(total) >= s.errorRate {
+			return fmt.Sprintf("error rate is %.2f%% (%d of %d transactions failed)",
+				float64(failed)/float64(total)*100, failed, total)
+		}
+	}
+
+	if s.head != nil {
+		blockNumber, err := s.head.BlockNumber(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Errorf("Error while fetching block number: %v", err)
+			}
+		} else if blockNumber >= s.blockNumber {
+			return fmt.Sprintf("block %d is reached", blockNumber)
+		}
+	}
+	return ""
+}
+
+// watch checks the stop conditions until one of them is met, then calls
+// stop with the reason. It returns without calling stop if ctx is done
+// before that.
+func (s *stopConditions) watch(ctx context.Context, stop func(reason string)) {
+	start := time.Now()
+
+	ticker := time.NewTicker(stopCheckInterval)
+	defer ticker.Stop()
+
+	var durationC <-chan time.Time
+	if s.duration > 0 {
+		timer := time.NewTimer(s.duration)
+		defer timer.Stop()
+		durationC = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-durationC:
+		}
+
+		if reason := s.check(ctx, time.Since(start)); reason != "" {
+			stop(reason)
+			return
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tubuarge/GoHammer/config"
+	"github.com/tubuarge/GoHammer/logger"
+)
+
+type fakeHead uint64
+
+func (f fakeHead) BlockNumber(ctx context.Context) (uint64, error) {
+	return uint64(f), nil
+}
+
+func TestStopConditionsCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		stopConfig config.StopConfig
+		stats      profileStats
+		elapsed    time.Duration
+		head       uint64
+		stop       bool
+	}{
+		{"duration not reached", config.StopConfig{Duration: "1h"}, profileStats{}, time.Minute, 0, false},
+		{"duration reached", config.StopConfig{Duration: "1h"}, profileStats{}, time.Hour, 0, true},
+		{"mined not reached", config.StopConfig{MinedTxs: 10}, profileStats{mined: 9}, 0, 0, false},
+		{"mined reached", config.StopConfig{MinedTxs: 10}, profileStats{mined: 10}, 0, 0, true},
+		{"error rate below", config.StopConfig{ErrorRate: 0.1}, profileStats{sent: 95, failed: 5}, 0, 0, false},
+		{"error rate above", config.StopConfig{ErrorRate: 0.1}, profileStats{sent: 90, failed: 10}, 0, 0, true},
+		{"error rate too few txs", config.StopConfig{ErrorRate: 0.1}, profileStats{sent: 5, failed: 5}, 0, 0, false},
+		{"error rate min txs", config.StopConfig{ErrorRate: 0.1, MinTxs: 10}, profileStats{sent: 5, failed: 5}, 0, 0, true},
+		{"block not reached", config.StopConfig{BlockNumber: 100}, profileStats{}, 0, 99, false},
+		{"block reached", config.StopConfig{BlockNumber: 100}, profileStats{}, 0, 100, true},
+	}
+	for _, test := range tests {
+		stats := test.stats
+		s, err := newStopConditions(&test.stopConfig, true, &stats)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if s.blockNumber > 0 {
+			s.head = fakeHead(test.head)
+		}
+		if reason := s.check(context.Background(), test.elapsed); (reason != "") != test.stop {
+			t.Errorf("%s: check() = %q, want stop: %v", test.name, reason, test.stop)
+		}
+	}
+}
+
+func TestNewStopConditionsInvalid(t *testing.T) {
+	invalid := []config.StopConfig{
+		{},
+		{Duration: "soon"},
+		{Duration: "-1s"},
+		{MinedTxs: -1},
+		{ErrorRate: 1.5},
+		{ErrorRate: 0.1, MinTxs: -1},
+	}
+	for _, stopConfig := range invalid {
+		if _, err := newStopConditions(&stopConfig, true, &profileStats{}); err == nil {
+			t.Errorf("newStopConditions(%+v) didn't fail", stopConfig)
+		}
+	}
+
+	// mined transactions are counted only if receipts are tracked.
+	if _, err := newStopConditions(&config.StopConfig{MinedTxs: 10}, false, &profileStats{}); err == nil {
+		t.Error("newStopConditions didn't fail without receipts")
+	}
+}
+
+func TestStopConditionsWatch(t *testing.T) {
+	s, err := newStopConditions(&config.StopConfig{Duration: "20ms"}, false, &profileStats{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stopped := make(chan string, 1)
+	go s.watch(context.Background(), func(reason string) { stopped <- reason })
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("test profile isn't stopped after its duration")
+	}
+}
+
+func TestRunNodeLoadRepeats(t *testing.T) {
+	d := NewDeployClient(logger.NewLogClient(nil))
+	d.Logger.TestResult = &logger.TestResults{}
+	testProfile := &config.TestProfile{Name: "test", Stop: &config.StopConfig{Duration: "1h"}}
+	nodeConfig := &config.NodeConfig{Name: "node1", DeployCounts: []int{2, 3}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the deploy counts are repeated until the test profile is stopped.
+	sent := 0
+	err := d.runNodeLoad(ctx, testProfile, nodeConfig, 0, func(context.Context) error {
+		sent++
+		if sent == 12 {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 12 {
+		t.Errorf("sent %d transactions, want 12", sent)
+	}
+}
+
+func TestSendTxsRecordsIssuedCount(t *testing.T) {
+	d := NewDeployClient(logger.NewLogClient(nil))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// the run is stopped long before the 1000 transactions are issued.
+	d.sendTxs(ctx, "node1", 100, 1, 1000, func(int) {})
+
+	results := d.Logger.TestResult.RateResults
+	if len(results) != 1 || results[0].TxCount == 0 || results[0].TxCount > 10 {
+		t.Errorf("rate results are %+v, want about 5 issued transactions", results)
+	}
+}
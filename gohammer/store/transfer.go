@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+// Transfer recipients other than a fixed address.
+const (
+	TransferToRandom  = "random"
+	TransferToSenders = "senders"
+)
+
+const DefaultTransferGasLimit = 21000
+
+// txSender sends a signed transaction, ethclient.Client satisfies it.
+type txSender interface {
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+}
+
+// Transfer is a native value transfer workload.
+type Transfer struct {
+	// nextRecipientIndex is the index of the next sender recipient, it is
+	// updated atomically so it is kept as the first field for 64-bit
+	// alignment.
+	nextRecipientIndex uint64
+
+	To       string
+	Amount   *big.Int
+	GasLimit uint64
+
+	// address is the recipient if To is an address.
+	address common.Address
+	random  *lockedRand
+}
+
+// LoadTransfer returns the transfer workload of the given config.
+func LoadTransfer(transferConfig *config.TransferConfig) (*Transfer, error) {
+	transfer := &Transfer{
+		To:       transferConfig.To,
+		Amount:   big.NewInt(1),
+		GasLimit: transferConfig.GasLimit,
+	}
+	if transfer.To == "" {
+		transfer.To = TransferToSenders
+	}
+	if transfer.GasLimit == 0 {
+		transfer.GasLimit = DefaultTransferGasLimit
+	}
+
+	switch transfer.To {
+	case TransferToSenders:
+	case TransferToRandom:
+		transfer.random = newLockedRand(time.Now().UnixNano())
+	default:
+		if !common.IsHexAddress(transfer.To) {
+			return nil, fmt.Errorf("invalid transfer recipient: %q", transfer.To)
+		}
+		transfer.address = common.HexToAddress(transfer.To)
+	}
+
+	if transferConfig.Amount != "" {
+		amount, err := toBigInt(transferConfig.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transfer amount: %v", err)
+		}
+		if amount.Sign() < 0 {
+			return nil, errors.New("transfer amount can't be negative")
+		}
+		transfer.Amount = amount
+	}
+
+	return transfer, nil
+}
+
+// recipient returns the recipient of the next transfer, senders are the
+// sender accounts of the node.
+func (t *Transfer) recipient(senders []*sender) common.Address {
+	switch t.To {
+	case TransferToSenders:
+		index := atomic.AddUint64(&t.nextRecipientIndex, 1) - 1
+		return senders[index%uint64(len(senders))].address
+	case TransferToRandom:
+		return common.BytesToAddress(t.random.read(common.AddressLength))
+	}
+	return t.address
+}
+
+// Sign returns a signed transfer to the given recipient without sending it.
+func (t *Transfer) Sign(opts *txOpts, to common.Address) (*types.Transaction, error) {
+	return opts.signTx(&to, t.Amount, t.GasLimit, nil)
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+func TestLoadTransfer(t *testing.T) {
+	transfer, err := LoadTransfer(&config.TransferConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.To != TransferToSenders || transfer.Amount.Cmp(big.NewInt(1)) != 0 || transfer.GasLimit != DefaultTransferGasLimit {
+		t.Errorf("unexpected defaults: %+v", transfer)
+	}
+
+	invalid := []*config.TransferConfig{
+		{To: "0x1234"},
+		{Amount: "1.5"},
+		{Amount: "-1"},
+	}
+	for _, transferConfig := range invalid {
+		if _, err := LoadTransfer(transferConfig); err == nil {
+			t.Errorf("LoadTransfer(%+v) didn't fail", transferConfig)
+		}
+	}
+}
+
+func TestTransferRecipient(t *testing.T) {
+	senders := []*sender{
+		{address: common.HexToAddress("0x01")},
+		{address: common.HexToAddress("0x02")},
+	}
+
+	transfer, _ := LoadTransfer(&config.TransferConfig{To: TransferToSenders})
+	for i, want := range []common.Address{senders[0].address, senders[1].address, senders[0].address} {
+		if got := transfer.recipient(senders); got != want {
+			t.Errorf("recipient %d = %s, want %s", i, got.Hex(), want.Hex())
+		}
+	}
+
+	fixed := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	transfer, _ = LoadTransfer(&config.TransferConfig{To: fixed.Hex()})
+	if got := transfer.recipient(senders); got != fixed {
+		t.Errorf("recipient = %s, want %s", got.Hex(), fixed.Hex())
+	}
+
+	transfer, _ = LoadTransfer(&config.TransferConfig{To: TransferToRandom})
+	if first, second := transfer.recipient(senders), transfer.recipient(senders); first == second {
+		t.Errorf("random recipients are the same: %s", first.Hex())
+	}
+}
+
+func TestTransferSign(t *testing.T) {
+	opts := newTestTxOpts(t, TxTypeLegacy, 1337)
+
+	transfer, err := LoadTransfer(&config.TransferConfig{Amount: "1000000000000000000", GasLimit: 25000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tx, err := transfer.Sign(opts, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *tx.To() != to || tx.Nonce() != 5 || tx.Gas() != 25000 || tx.Value().String() != "1000000000000000000" || len(tx.Data()) != 0 {
+		t.Errorf("unexpected transaction: to %s, nonce %d, gas %d, value %s", tx.To().Hex(), tx.Nonce(), tx.Gas(), tx.Value())
+	}
+	from, err := types.Sender(types.NewEIP155Signer(big.NewInt(1337)), tx)
+	if err != nil || from != opts.From {
+		t.Errorf("transaction sender = %s (%v), want %s", from.Hex(), err, opts.From.Hex())
+	}
+}
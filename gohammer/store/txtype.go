@@ -0,0 +1,124 @@
+package store
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+// Transaction types.
+const (
+	TxTypeLegacy     = "legacy"
+	TxTypeAccessList = "accessList"
+	TxTypeDynamicFee = "dynamicFee"
+)
+
+// txOpts are the options of the next transaction of a node. Every workload
+// builds its transactions with signTx, so they have the configured type and
+// are signed with the chain ID of the node. bind.BoundContract isn't used to
+// build them: in go-ethereum v1.10.8 it can't build access list transactions
+// and it fetches the latest header for every transaction to choose between
+// legacy and dynamic fee transactions, which would add a request to the
+// load of every sent transaction. The contract ABI still packs the calls.
+type txOpts struct {
+	*bind.TransactOpts
+
+	txType     string
+	chainID    *big.Int
+	accessList types.AccessList
+	gas        *gasPolicy
+}
+
+// signTx returns a signed transaction of the configured type with the nonce
+// and fees of the options. Its gas limit is chosen by the gas policy,
+// defaultGas is the gas limit of the workload.
+func (o *txOpts) signTx(to *common.Address, value *big.Int, defaultGas uint64, data []byte) (*types.Transaction, error) {
+	gas, err := o.gas.gasLimit(o.Context, ethereum.CallMsg{
+		From:       o.From,
+		To:         to,
+		GasPrice:   o.GasPrice,
+		GasFeeCap:  o.GasFeeCap,
+		GasTipCap:  o.GasTipCap,
+		Value:      value,
+		Data:       data,
+		AccessList: o.accessList,
+	}, defaultGas)
+	if err != nil {
+		return nil, err
+	}
+
+	var txData types.TxData
+	switch o.txType {
+	case TxTypeAccessList:
+		txData = &types.AccessListTx{
+			ChainID:    o.chainID,
+			Nonce:      o.Nonce.Uint64(),
+			GasPrice:   o.GasPrice,
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: o.accessList,
+		}
+	case TxTypeDynamicFee:
+		txData = &types.DynamicFeeTx{
+			ChainID:    o.chainID,
+			Nonce:      o.Nonce.Uint64(),
+			GasTipCap:  o.GasTipCap,
+			GasFeeCap:  o.GasFeeCap,
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: o.accessList,
+		}
+	default:
+		txData = &types.LegacyTx{
+			Nonce:    o.Nonce.Uint64(),
+			GasPrice: o.GasPrice,
+			Gas:      gas,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		}
+	}
+	return o.Signer(o.From, types.NewTx(txData))
+}
+
+// parseTxType validates the transaction type of a test profile.
+func parseTxType(txType string) (string, error) {
+	switch txType {
+	case "":
+		return TxTypeLegacy, nil
+	case TxTypeLegacy, TxTypeAccessList, TxTypeDynamicFee:
+		return txType, nil
+	}
+	return "", fmt.Errorf("unknown transaction type: %q", txType)
+}
+
+// parseAccessList converts the access list of a test profile.
+func parseAccessList(tuples []config.AccessTuple) (types.AccessList, error) {
+	var accessList types.AccessList
+	for _, tuple := range tuples {
+		if !common.IsHexAddress(tuple.Address) {
+			return nil, fmt.Errorf("invalid access list address: %q", tuple.Address)
+		}
+		accessTuple := types.AccessTuple{Address: common.HexToAddress(tuple.Address)}
+		for _, key := range tuple.StorageKeys {
+			data, err := hexutil.Decode(key)
+			if err != nil || len(data) > common.HashLength {
+				return nil, fmt.Errorf("invalid access list storage key: %q", key)
+			}
+			accessTuple.StorageKeys = append(accessTuple.StorageKeys, common.BytesToHash(data))
+		}
+		accessList = append(accessList, accessTuple)
+	}
+	return accessList, nil
+}
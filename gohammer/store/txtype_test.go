@@ -0,0 +1,117 @@
+package store
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/tubuarge/GoHammer/config"
+)
+
+// newTestTxOpts returns the options of a transaction of the given type from a
+// new account, signed with the given chain ID.
+func newTestTxOpts(t *testing.T, txType string, chainID int64) *txOpts {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, big.NewInt(chainID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth.Nonce = big.NewInt(5)
+	auth.Value = big.NewInt(0)
+	auth.GasLimit = 300000
+	if txType == TxTypeDynamicFee {
+		auth.GasFeeCap = big.NewInt(2000)
+		auth.GasTipCap = big.NewInt(100)
+	} else {
+		auth.GasPrice = big.NewInt(1000)
+	}
+	gas, err := newGasPolicy(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &txOpts{TransactOpts: auth, txType: txType, chainID: big.NewInt(chainID), gas: gas}
+}
+
+func TestTxOptsSignTx(t *testing.T) {
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	accessList := types.AccessList{{Address: to, StorageKeys: []common.Hash{{1}}}}
+
+	tests := []struct {
+		txType string
+		want   uint8
+	}{
+		{TxTypeLegacy, types.LegacyTxType},
+		{TxTypeAccessList, types.AccessListTxType},
+		{TxTypeDynamicFee, types.DynamicFeeTxType},
+	}
+	for _, test := range tests {
+		opts := newTestTxOpts(t, test.txType, 1337)
+		opts.accessList = accessList
+
+		tx, err := opts.signTx(&to, big.NewInt(7), 21000, []byte{1})
+		if err != nil {
+			t.Fatalf("%s: %v", test.txType, err)
+		}
+
+		if tx.Type() != test.want {
+			t.Errorf("%s: transaction type = %d, want %d", test.txType, tx.Type(), test.want)
+		}
+		if tx.ChainId().Int64() != 1337 || !tx.Protected() {
+			t.Errorf("%s: transaction is not signed with the chain id", test.txType)
+		}
+		if tx.Nonce() != 5 || *tx.To() != to || tx.Value().Int64() != 7 || tx.Gas() != 21000 {
+			t.Errorf("%s: unexpected transaction: nonce %d, to %s, value %s, gas %d",
+				test.txType, tx.Nonce(), tx.To().Hex(), tx.Value(), tx.Gas())
+		}
+		if test.txType != TxTypeLegacy && len(tx.AccessList()) != 1 {
+			t.Errorf("%s: access list is missing", test.txType)
+		}
+		if test.txType == TxTypeDynamicFee && (tx.GasFeeCap().Int64() != 2000 || tx.GasTipCap().Int64() != 100) {
+			t.Errorf("%s: fees = %s/%s, want 2000/100", test.txType, tx.GasFeeCap(), tx.GasTipCap())
+		}
+
+		from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1337)), tx)
+		if err != nil || from != opts.From {
+			t.Errorf("%s: transaction sender = %s (%v), want %s", test.txType, from.Hex(), err, opts.From.Hex())
+		}
+	}
+}
+
+func TestParseTxType(t *testing.T) {
+	if txType, err := parseTxType(""); err != nil || txType != TxTypeLegacy {
+		t.Errorf("parseTxType(\"\") = %q, %v, want legacy", txType, err)
+	}
+	if _, err := parseTxType("blob"); err == nil {
+		t.Error("parseTxType didn't fail on an unknown type")
+	}
+}
+
+func TestParseAccessList(t *testing.T) {
+	accessList, err := parseAccessList([]config.AccessTuple{{
+		Address:     "0x00000000000000000000000000000000000000aa",
+		StorageKeys: []string{"0x01"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accessList) != 1 || accessList[0].StorageKeys[0] != (common.Hash{31: 1}) {
+		t.Errorf("unexpected access list: %v", accessList)
+	}
+
+	invalid := [][]config.AccessTuple{
+		{{Address: "0x1234"}},
+		{{Address: "0x00000000000000000000000000000000000000aa", StorageKeys: []string{"zz"}}},
+	}
+	for _, tuples := range invalid {
+		if _, err := parseAccessList(tuples); err == nil {
+			t.Errorf("parseAccessList(%v) didn't fail", tuples)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package util
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -57,6 +59,45 @@ func ParseDuration(str string) (time.Duration, error) {
 	return duration, nil
 }
 
+// ParseRate parses a rate string like "200/s", "30/m" or "5/100ms" and
+// returns it as transactions per second.
+func ParseRate(str string) (float64, error) {
+	parts := strings.Split(strings.TrimSpace(str), "/")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid rate %q, expected <count>/<unit>", str)
+	}
+
+	count, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate count %q: %v", parts[0], err)
+	}
+	if math.IsNaN(count) || math.IsInf(count, 0) {
+		return 0, fmt.Errorf("rate count must be finite: %q", str)
+	}
+	if count <= 0 {
+		return 0, fmt.Errorf("rate count must be positive: %q", str)
+	}
+
+	unit := parts[1]
+	switch unit {
+	case "s", "m", "h":
+		unit = "1" + unit
+	}
+	per, err := ParseDuration(unit)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate unit %q: %v", parts[1], err)
+	}
+	if per <= 0 {
+		return 0, fmt.Errorf("rate unit must be positive: %q", str)
+	}
+
+	rate := count / per.Seconds()
+	if math.IsInf(rate, 0) {
+		return 0, fmt.Errorf("rate is too large: %q", str)
+	}
+	return rate, nil
+}
+
 func GetTestEntrySeperatorStr() string {
 	return fmt.Sprintf("%s\n", strings.Repeat("=", 77))
 
@@ -0,0 +1,42 @@
+package util
+
+import "testing"
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		str     string
+		want    float64
+		wantErr bool
+	}{
+		{str: "200/s", want: 200},
+		{str: "60/m", want: 1},
+		{str: "3600/h", want: 1},
+		{str: "5/100ms", want: 50},
+		{str: "0.5/s", want: 0.5},
+		{str: "200", wantErr: true},
+		{str: "abc/s", wantErr: true},
+		{str: "0/s", wantErr: true},
+		{str: "10/x", wantErr: true},
+		{str: "NaN/s", wantErr: true},
+		{str: "Inf/s", wantErr: true},
+		{str: "-Inf/s", wantErr: true},
+		{str: "1e308/1ns", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseRate(test.str)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseRate(%q) expected error, got %v", test.str, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRate(%q) unexpected error: %v", test.str, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseRate(%q) = %v, want %v", test.str, got, test.want)
+		}
+	}
+}